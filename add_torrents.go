@@ -0,0 +1,239 @@
+// go-libdeluge v0.5.6 - a native deluge RPC client library
+// Copyright (C) 2015~2023 gdm85 - https://github.com/gdm85/go-libdeluge/
+// This program is free software; you can redistribute it and/or
+// modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; either version 2
+// of the License, or (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// You should have received a copy of the GNU General Public License
+// along with this program; if not, write to the Free Software
+// Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+
+package deluge
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+
+	"github.com/gdm85/go-rencode"
+)
+
+// addTorrentFilesBatchSize is the maximum number of torrents sent with a single
+// core.add_torrent_files call, to keep requests to a reasonable size.
+const addTorrentFilesBatchSize = 100
+
+var (
+	// ErrTorrentNotAdded is returned for a torrent which was not found in the session after adding it.
+	ErrTorrentNotAdded = errors.New("torrent not added")
+)
+
+// TorrentFile is a .torrent file to be added to the session.
+type TorrentFile struct {
+	FileName string
+	// Content is the raw (not base64 encoded) content of the .torrent file.
+	Content []byte
+	Options *Options
+}
+
+// AddTorrentResult is the outcome of adding a single torrent.
+type AddTorrentResult struct {
+	FileName string
+	// Hash is the info hash of the torrent, also set when the torrent was already present.
+	Hash string
+	// AlreadyPresent is true when the torrent was already in the session before adding it.
+	AlreadyPresent bool
+	// Err is set when the torrent could not be added.
+	Err error
+}
+
+// AddTorrentFiles adds many .torrent files at once and returns a result for each of them,
+// in the same order.
+// Info hashes are computed locally (invalid files get an InvalidTorrentError), the
+// torrents already in the session are skipped and the others are sent in batches;
+// after each batch the session is queried to verify which were added, and those missing
+// are retried one at a time to report the daemon's error for each of them.
+// On v1 daemons, which lack core.add_torrent_files, torrents are added one at a time.
+// When the daemon cannot be reached the results are returned together with the error,
+// which is also set on every torrent whose outcome is unknown.
+func (c *Client) AddTorrentFiles(ctx context.Context, files []TorrentFile) ([]AddTorrentResult, error) {
+	results := make([]AddTorrentResult, len(files))
+	var hashes []string
+	for i, f := range files {
+		results[i].FileName = f.FileName
+
+		m, err := ParseMetainfo(f.FileName, f.Content)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		results[i].Hash = m.Hash
+
+		_, err = f.Options.toDictionary(c.v2daemon)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		hashes = append(hashes, m.Hash)
+	}
+	if len(hashes) == 0 {
+		return results, nil
+	}
+
+	present, err := c.presentHashes(ctx, hashes)
+	if err != nil {
+		for i := range results {
+			if results[i].Err == nil {
+				results[i].Err = err
+			}
+		}
+		return results, err
+	}
+
+	var pending []int
+	for i := range results {
+		if results[i].Err != nil {
+			continue
+		}
+		if present[results[i].Hash] {
+			results[i].AlreadyPresent = true
+			continue
+		}
+		// the same torrent may be specified more than once
+		present[results[i].Hash] = true
+		pending = append(pending, i)
+	}
+
+	for start := 0; start < len(pending); start += addTorrentFilesBatchSize {
+		end := start + addTorrentFilesBatchSize
+		if end > len(pending) {
+			end = len(pending)
+		}
+		err = c.addTorrentFilesBatch(ctx, files, results, pending[start:end])
+		if err != nil {
+			setUnknownResults(results, pending[end:], err)
+			return results, err
+		}
+	}
+
+	return results, nil
+}
+
+// addTorrentFilesBatch adds the torrents at the given indexes and verifies they are in the session.
+// On error, the torrents of the batch whose outcome is unknown get the error in their result.
+func (c *Client) addTorrentFilesBatch(ctx context.Context, files []TorrentFile, results []AddTorrentResult, indexes []int) error {
+	if c.v2daemon {
+		var list rencode.List
+		for _, i := range indexes {
+			f := files[i]
+			dict, err := f.Options.toDictionary(c.v2daemon)
+			if err != nil {
+				return err
+			}
+			list.Add(rencode.NewList(f.FileName, base64.StdEncoding.EncodeToString(f.Content), dict))
+		}
+
+		var args rencode.List
+		args.Add(list)
+
+		// the returned errors do not say which torrent they belong to, the torrents
+		// not added are retried below to get their own error
+		err := c.rpcWithNoResult(ctx, "core.add_torrent_files", args, rencode.Dictionary{})
+		if err != nil {
+			setUnknownResults(results, indexes, err)
+			return err
+		}
+	} else {
+		err := c.addTorrentFilesOneByOne(ctx, files, results, indexes)
+		if err != nil {
+			return err
+		}
+	}
+
+	var hashes []string
+	for _, i := range indexes {
+		if results[i].Err == nil {
+			hashes = append(hashes, results[i].Hash)
+		}
+	}
+	if len(hashes) == 0 {
+		return nil
+	}
+	added, err := c.presentHashes(ctx, hashes)
+	if err != nil {
+		setUnknownResults(results, indexes, err)
+		return err
+	}
+
+	var missing []int
+	for _, i := range indexes {
+		if results[i].Err == nil && !added[results[i].Hash] {
+			missing = append(missing, i)
+		}
+	}
+	if !c.v2daemon {
+		for _, i := range missing {
+			results[i].Err = ErrTorrentNotAdded
+		}
+		return nil
+	}
+
+	return c.addTorrentFilesOneByOne(ctx, files, results, missing)
+}
+
+// addTorrentFilesOneByOne adds the torrents at the given indexes with core.add_torrent_file,
+// setting the daemon's error in the result of each torrent which could not be added.
+func (c *Client) addTorrentFilesOneByOne(ctx context.Context, files []TorrentFile, results []AddTorrentResult, indexes []int) error {
+	for n, i := range indexes {
+		f := files[i]
+		hash, err := c.AddTorrentFile(ctx, f.FileName, base64.StdEncoding.EncodeToString(f.Content), f.Options)
+		if isAlreadyInSession(err) {
+			// added in the meantime
+			results[i].AlreadyPresent = true
+			continue
+		}
+		if err != nil {
+			var rpcErr RPCError
+			if errors.As(err, &rpcErr) {
+				results[i].Err = err
+				continue
+			}
+			setUnknownResults(results, indexes[n:], err)
+			return err
+		}
+		if hash == "" {
+			results[i].Err = ErrTorrentNotAdded
+		}
+	}
+	return nil
+}
+
+// setUnknownResults sets err on the results at the given indexes which have no error yet.
+func setUnknownResults(results []AddTorrentResult, indexes []int, err error) {
+	for _, i := range indexes {
+		if results[i].Err == nil {
+			results[i].Err = err
+		}
+	}
+}
+
+// presentHashes returns which of the given hashes are in the session.
+func (c *Client) presentHashes(ctx context.Context, hashes []string) (map[string]bool, error) {
+	var filterDict rencode.Dictionary
+	filterDict.Add("id", sliceToRencodeList(hashes))
+
+	d, err := c.torrentsStatusKeys(ctx, filterDict, "hash")
+	if err != nil {
+		return nil, err
+	}
+
+	present := make(map[string]bool, len(d))
+	for id := range d {
+		present[id] = true
+	}
+
+	return present, nil
+}
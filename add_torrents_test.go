@@ -0,0 +1,178 @@
+package deluge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/gdm85/go-rencode"
+)
+
+func testTorrent(name string) []byte {
+	return []byte(fmt.Sprintf("d4:infod6:lengthi1024e4:name%d:%s12:piece lengthi16384e6:pieces20:aaaaaaaaaaaaaaaaaaaaee", len(name), name))
+}
+
+func TestAddTorrentFiles(t *testing.T) {
+	t.Parallel()
+
+	files := []TorrentFile{
+		{FileName: "a.torrent", Content: testTorrent("a")},
+		{FileName: "b.torrent", Content: testTorrent("b")},
+		{FileName: "c.torrent", Content: testTorrent("c")},
+		{FileName: "bad.torrent", Content: []byte("<html>")},
+	}
+	var hashes []string
+	for _, f := range files[:3] {
+		_, d, err := decodeBencode(f.Content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h, _ := d.infoHashV1()
+		hashes = append(hashes, h)
+	}
+
+	// a is already present, b is added, c is rejected by the daemon
+	session := map[string]bool{hashes[0]: true}
+	batches, retries := 0, 0
+	c := newFakeClientV2(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		switch method {
+		case "core.get_torrents_status":
+			var d rencode.Dictionary
+			for h := range session {
+				var st rencode.Dictionary
+				st.Add("hash", h)
+				d.Add(h, st)
+			}
+			return d, nil
+		case "core.add_torrent_files":
+			batches++
+			var list rencode.List
+			err := args.Scan(&list)
+			if err != nil {
+				return nil, err
+			}
+			if list.Length() != 2 {
+				t.Errorf("expected 2 torrents to be sent, got %d", list.Length())
+			}
+			session[hashes[1]] = true
+			return rencode.List{}, nil
+		case "core.add_torrent_file":
+			retries++
+			return nil, RPCError{ExceptionType: "AddTorrentError", ExceptionMessage: "Unable to add torrent"}
+		}
+		return nil, RPCError{ExceptionType: "AttributeError", ExceptionMessage: method}
+	})
+
+	results, err := c.AddTorrentFiles(context.Background(), files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if batches != 1 {
+		t.Errorf("expected 1 batch, got %d", batches)
+	}
+	if retries != 1 {
+		t.Errorf("expected 1 retry, got %d", retries)
+	}
+
+	if !results[0].AlreadyPresent || results[0].Err != nil || results[0].Hash != hashes[0] {
+		t.Errorf("unexpected result %+v", results[0])
+	}
+	if results[1].AlreadyPresent || results[1].Err != nil || results[1].Hash != hashes[1] {
+		t.Errorf("unexpected result %+v", results[1])
+	}
+	var rpcErr RPCError
+	if !errors.As(results[2].Err, &rpcErr) || rpcErr.ExceptionType != "AddTorrentError" {
+		t.Errorf("unexpected result %+v", results[2])
+	}
+	if results[3].Err == nil {
+		t.Errorf("unexpected result %+v", results[3])
+	}
+}
+
+func TestAddTorrentFilesV1(t *testing.T) {
+	t.Parallel()
+
+	files := []TorrentFile{
+		{FileName: "a.torrent", Content: testTorrent("a")},
+		{FileName: "b.torrent", Content: testTorrent("b")},
+	}
+	m, err := ParseMetainfo(files[0].FileName, files[0].Content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session := map[string]bool{}
+	c := newFakeClient(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		switch method {
+		case "core.get_torrents_status":
+			var d rencode.Dictionary
+			for h := range session {
+				var st rencode.Dictionary
+				st.Add("hash", h)
+				d.Add(h, st)
+			}
+			return d, nil
+		case "core.add_torrent_file":
+			var fileName string
+			err := args.Scan(&fileName)
+			if err != nil {
+				return nil, err
+			}
+			if fileName == "b.torrent" {
+				return nil, RPCError{ExceptionType: "RuntimeError", ExceptionMessage: "invalid torrent"}
+			}
+			session[m.Hash] = true
+			return m.Hash, nil
+		}
+		return nil, RPCError{ExceptionType: "AttributeError", ExceptionMessage: method}
+	})
+
+	results, err := c.AddTorrentFiles(context.Background(), files)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if results[0].Err != nil || results[0].Hash != m.Hash {
+		t.Errorf("unexpected result %+v", results[0])
+	}
+	var rpcErr RPCError
+	if !errors.As(results[1].Err, &rpcErr) || rpcErr.ExceptionMessage != "invalid torrent" {
+		t.Errorf("unexpected result %+v", results[1])
+	}
+}
+
+func TestAddTorrentFilesConnectionLost(t *testing.T) {
+	t.Parallel()
+
+	files := []TorrentFile{
+		{FileName: "a.torrent", Content: testTorrent("a")},
+		{FileName: "bad.torrent", Content: []byte("<html>")},
+	}
+
+	c := newFakeClientV2(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		switch method {
+		case "core.get_torrents_status":
+			return rencode.Dictionary{}, nil
+		case "core.add_torrent_files":
+			return nil, io.EOF
+		}
+		return nil, RPCError{ExceptionType: "AttributeError", ExceptionMessage: method}
+	})
+
+	results, err := c.AddTorrentFiles(context.Background(), files)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if results[0].Err != err {
+		t.Errorf("unexpected result %+v", results[0])
+	}
+	var invalid InvalidTorrentError
+	if !errors.As(results[1].Err, &invalid) {
+		t.Errorf("unexpected result %+v", results[1])
+	}
+}
@@ -0,0 +1,534 @@
+// go-libdeluge v0.5.6 - a native deluge RPC client library
+// Copyright (C) 2015~2023 gdm85 - https://github.com/gdm85/go-libdeluge/
+// This program is free software; you can redistribute it and/or
+// modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; either version 2
+// of the License, or (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// You should have received a copy of the GNU General Public License
+// along with this program; if not, write to the Free Software
+// Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+
+package deluge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gdm85/go-rencode"
+)
+
+// labelPluginName is the name of the plugin required to manage labels.
+const labelPluginName = "Label"
+
+// localClientUsername is the account used by clients on the daemon host, which is never pruned.
+const localClientUsername = "localclient"
+
+var (
+	// ErrInvalidDesiredState is returned when a desired state cannot be loaded or planned.
+	ErrInvalidDesiredState = errors.New("invalid desired state")
+)
+
+// DesiredState is the configuration a daemon is reconciled to by Apply; nil
+// sections are left untouched.
+type DesiredState struct {
+	// Config lists the core.conf keys to enforce.
+	Config *CoreConfig
+	// Plugins is the exact list of enabled plugins.
+	Plugins []string
+	// Labels maps the labels to their options; nil options only ensure the label exists.
+	// The Label plugin must be enabled or listed in Plugins.
+	Labels map[string]*LabelOptions
+	// Accounts are created or updated; v2 daemons only.
+	Accounts []Account
+	// Prune removes the labels and the accounts which are not listed, when the
+	// corresponding section is not nil.
+	Prune bool
+}
+
+// ParseDesiredState loads a desired state from JSON or, by passing the Unmarshal
+// function of a YAML library, from YAML.
+// Keys are snake case like in core.conf, e.g. "config", "max_upload_speed" and "auth_level".
+func ParseDesiredState(data []byte, unmarshal func([]byte, interface{}) error) (*DesiredState, error) {
+	if unmarshal == nil {
+		unmarshal = json.Unmarshal
+	}
+
+	var raw interface{}
+	err := unmarshal(data, &raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDesiredState, err)
+	}
+	top, ok := normalizeRencode(raw).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: not a dictionary", ErrInvalidDesiredState)
+	}
+
+	var ds DesiredState
+	for key, value := range top {
+		switch key {
+		case "config":
+			m, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%w: config is not a dictionary", ErrInvalidDesiredState)
+			}
+			ds.Config = &CoreConfig{}
+			err = ds.Config.fromMap(m)
+		case "plugins":
+			err = assignRencode(value, reflect.ValueOf(&ds.Plugins).Elem())
+		case "labels":
+			err = assignRencode(value, reflect.ValueOf(&ds.Labels).Elem())
+			if err == nil && ds.Labels == nil {
+				ds.Labels = map[string]*LabelOptions{}
+			}
+		case "accounts":
+			err = assignRencode(value, reflect.ValueOf(&ds.Accounts).Elem())
+		case "prune":
+			err = assignRencode(value, reflect.ValueOf(&ds.Prune).Elem())
+		default:
+			err = errors.New("unknown key")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %v", ErrInvalidDesiredState, key, err)
+		}
+	}
+
+	return &ds, nil
+}
+
+// ChangeKind is the section of the desired state a change belongs to.
+type ChangeKind string
+
+const (
+	ChangeConfig  ChangeKind = "config"
+	ChangePlugin  ChangeKind = "plugin"
+	ChangeLabel   ChangeKind = "label"
+	ChangeAccount ChangeKind = "account"
+)
+
+// ChangeAction is what a change does; plugins are enabled or disabled with
+// ActionAdd and ActionRemove.
+type ChangeAction string
+
+const (
+	ActionAdd    ChangeAction = "add"
+	ActionUpdate ChangeAction = "update"
+	ActionRemove ChangeAction = "remove"
+)
+
+// StateChange is a single difference between the daemon and the desired state.
+type StateChange struct {
+	Kind   ChangeKind
+	Action ChangeAction
+	// Name is the config key, plugin name, label or account username.
+	Name string
+	// Key is the option of a label being updated.
+	Key string
+	// Old and New are normalized values (see GetConfigValue) or, for accounts, Account values.
+	Old, New interface{}
+}
+
+func (sc StateChange) String() string {
+	symbol := map[ChangeAction]string{ActionAdd: "+", ActionUpdate: "~", ActionRemove: "-"}[sc.Action]
+
+	switch {
+	case sc.Kind == ChangeAccount:
+		var details []string
+		old, _ := sc.Old.(Account)
+		if a, ok := sc.New.(Account); ok {
+			if old.AuthLevel != a.AuthLevel {
+				details = append(details, fmt.Sprintf("auth level %s", formatChange(old.AuthLevel, a.AuthLevel, sc.Action)))
+			}
+			if sc.Action == ActionUpdate && old.Password != a.Password {
+				// passwords are never shown
+				details = append(details, "password changed")
+			}
+		}
+		if len(details) == 0 {
+			return fmt.Sprintf("%s account %s", symbol, sc.Name)
+		}
+		return fmt.Sprintf("%s account %s: %s", symbol, sc.Name, strings.Join(details, ", "))
+	case sc.Kind == ChangeConfig:
+		return fmt.Sprintf("%s config %s: %s", symbol, sc.Name, formatChange(sc.Old, sc.New, sc.Action))
+	case sc.Key != "":
+		return fmt.Sprintf("%s %s %s %s: %s", symbol, sc.Kind, sc.Name, sc.Key, formatChange(sc.Old, sc.New, sc.Action))
+	}
+	return fmt.Sprintf("%s %s %s", symbol, sc.Kind, sc.Name)
+}
+
+func formatChange(old, new interface{}, action ChangeAction) string {
+	if action == ActionAdd || old == nil {
+		return fmt.Sprintf("%v", new)
+	}
+	return fmt.Sprintf("%v -> %v", old, new)
+}
+
+// StatePlan is the ordered list of changes needed to reach a desired state.
+type StatePlan struct {
+	Changes []StateChange
+}
+
+// Empty returns true when the daemon is already in the desired state.
+func (p *StatePlan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// String returns the changes one per line, suitable to be reviewed.
+func (p *StatePlan) String() string {
+	if p.Empty() {
+		return "no changes\n"
+	}
+	var sb strings.Builder
+	for _, sc := range p.Changes {
+		sb.WriteString(sc.String())
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// Plan reads the daemon state and returns the changes needed to reach the desired state,
+// without changing anything.
+func (c *Client) Plan(ctx context.Context, desired *DesiredState) (*StatePlan, error) {
+	if len(desired.Accounts) != 0 && !c.v2daemon {
+		return nil, fmt.Errorf("%w: accounts require a v2 daemon", ErrInvalidDesiredState)
+	}
+
+	var plan StatePlan
+
+	enabled, err := c.GetEnabledPlugins(ctx)
+	if err != nil {
+		return nil, err
+	}
+	labelEnabled := containsString(enabled, labelPluginName)
+	if desired.Plugins != nil {
+		for _, name := range sortedDifference(desired.Plugins, enabled) {
+			plan.Changes = append(plan.Changes, StateChange{Kind: ChangePlugin, Action: ActionAdd, Name: name})
+		}
+		for _, name := range sortedDifference(enabled, desired.Plugins) {
+			plan.Changes = append(plan.Changes, StateChange{Kind: ChangePlugin, Action: ActionRemove, Name: name})
+		}
+		if desired.Labels != nil && !containsString(desired.Plugins, labelPluginName) {
+			return nil, fmt.Errorf("%w: labels require the %s plugin", ErrInvalidDesiredState, labelPluginName)
+		}
+	} else if desired.Labels != nil && !labelEnabled {
+		return nil, fmt.Errorf("%w: labels require the %s plugin", ErrInvalidDesiredState, labelPluginName)
+	}
+
+	if desired.Config != nil {
+		changes, err := c.planConfig(ctx, desired.Config)
+		if err != nil {
+			return nil, err
+		}
+		plan.Changes = append(plan.Changes, changes...)
+	}
+
+	if desired.Labels != nil {
+		changes, err := c.planLabels(ctx, desired.Labels, desired.Prune, labelEnabled)
+		if err != nil {
+			return nil, err
+		}
+		plan.Changes = append(plan.Changes, changes...)
+	}
+
+	if desired.Accounts != nil {
+		changes, err := c.planAccounts(ctx, desired.Accounts, desired.Prune)
+		if err != nil {
+			return nil, err
+		}
+		plan.Changes = append(plan.Changes, changes...)
+	}
+
+	return &plan, nil
+}
+
+func (c *Client) planConfig(ctx context.Context, desired *CoreConfig) ([]StateChange, error) {
+	// fail early on fields not supported by the daemon version
+	_, err := desired.toDictionary(c.v2daemon)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDesiredState, err)
+	}
+
+	// the keys are taken from the returned map, as fields set to None are nil in a CoreConfig
+	currentValues, err := c.getConfigMap(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	values := desired.values()
+	var changes []StateChange
+	for _, key := range sortedKeys(values) {
+		value := values[key]
+		old, ok := currentValues[key]
+		if !ok {
+			// the daemon would silently add unknown keys, which is likely a typo
+			return nil, fmt.Errorf("%w: unknown config key %q", ErrInvalidDesiredState, key)
+		}
+		if !valuesEqual(old, value) {
+			changes = append(changes, StateChange{Kind: ChangeConfig, Action: ActionUpdate, Name: key, Old: old, New: value})
+		}
+	}
+
+	return changes, nil
+}
+
+func (c *Client) planLabels(ctx context.Context, desired map[string]*LabelOptions, prune, labelEnabled bool) ([]StateChange, error) {
+	p := LabelPlugin{Client: c}
+	var labels []string
+	if labelEnabled {
+		var err error
+		labels, err = p.GetLabels(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var changes []StateChange
+	for _, label := range sortedKeys(desired) {
+		values := desired[label].values()
+		var current map[string]interface{}
+		if containsString(labels, label) {
+			options, err := p.GetLabelOptions(ctx, label)
+			if err != nil {
+				return nil, err
+			}
+			current = options.values()
+		} else {
+			changes = append(changes, StateChange{Kind: ChangeLabel, Action: ActionAdd, Name: label})
+		}
+
+		for _, key := range sortedKeys(values) {
+			old, ok := current[key]
+			if ok && valuesEqual(old, values[key]) {
+				continue
+			}
+			changes = append(changes, StateChange{Kind: ChangeLabel, Action: ActionUpdate, Name: label, Key: key, Old: old, New: values[key]})
+		}
+	}
+
+	if prune {
+		for _, label := range labels {
+			if _, ok := desired[label]; !ok {
+				changes = append(changes, StateChange{Kind: ChangeLabel, Action: ActionRemove, Name: label})
+			}
+		}
+	}
+
+	return changes, nil
+}
+
+func (c *Client) planAccounts(ctx context.Context, desired []Account, prune bool) ([]StateChange, error) {
+	accounts, err := c.knownAccounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	current := make(map[string]Account, len(accounts))
+	for _, a := range accounts {
+		current[a.Username] = a
+	}
+
+	var changes []StateChange
+	wanted := map[string]bool{}
+	for _, a := range desired {
+		if a.AuthLevel == "" {
+			a.AuthLevel = AuthLevelDefault
+		}
+		if wanted[a.Username] {
+			return nil, fmt.Errorf("%w: duplicate account %q", ErrInvalidDesiredState, a.Username)
+		}
+		wanted[a.Username] = true
+
+		old, ok := current[a.Username]
+		if !ok {
+			changes = append(changes, StateChange{Kind: ChangeAccount, Action: ActionAdd, Name: a.Username, New: a})
+		} else if old != a {
+			changes = append(changes, StateChange{Kind: ChangeAccount, Action: ActionUpdate, Name: a.Username, Old: old, New: a})
+		}
+	}
+
+	if prune {
+		for _, a := range accounts {
+			if !wanted[a.Username] && a.Username != localClientUsername {
+				changes = append(changes, StateChange{Kind: ChangeAccount, Action: ActionRemove, Name: a.Username, Old: a})
+			}
+		}
+	}
+
+	return changes, nil
+}
+
+// ApplyPlan applies the changes of a plan, in order; config keys and the options
+// of each label are sent with a single call.
+// The daemon state is not read again, so a plan should be applied shortly after being made.
+func (c *Client) ApplyPlan(ctx context.Context, plan *StatePlan) error {
+	p := LabelPlugin{Client: c}
+	b := planBatch{
+		config:       map[string]interface{}{},
+		labelOptions: map[string]map[string]interface{}{},
+	}
+
+	for _, sc := range plan.Changes {
+		// batched changes are sent before the first change of another kind
+		err := b.flush(ctx, c, sc.Kind)
+		if err != nil {
+			return err
+		}
+
+		switch sc.Kind {
+		case ChangePlugin:
+			if sc.Action == ActionAdd {
+				err = c.EnablePlugin(ctx, sc.Name)
+			} else {
+				err = c.DisablePlugin(ctx, sc.Name)
+			}
+		case ChangeConfig:
+			b.config[sc.Name] = sc.New
+		case ChangeLabel:
+			switch sc.Action {
+			case ActionAdd:
+				err = p.AddLabel(ctx, sc.Name)
+			case ActionUpdate:
+				if b.labelOptions[sc.Name] == nil {
+					b.labelOptions[sc.Name] = map[string]interface{}{}
+					b.labels = append(b.labels, sc.Name)
+				}
+				b.labelOptions[sc.Name][sc.Key] = sc.New
+			case ActionRemove:
+				err = p.RemoveLabel(ctx, sc.Name)
+			}
+		case ChangeAccount:
+			err = c.applyAccountChange(ctx, sc)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", sc, err)
+		}
+	}
+
+	return b.flush(ctx, c, "")
+}
+
+// planBatch holds the config keys and label options not yet sent by ApplyPlan.
+type planBatch struct {
+	config       map[string]interface{}
+	labelOptions map[string]map[string]interface{}
+	labels       []string
+}
+
+// flush sends the changes batched for kinds other than the given one.
+func (b *planBatch) flush(ctx context.Context, c *Client, kind ChangeKind) error {
+	if kind != ChangeConfig && len(b.config) != 0 {
+		var args rencode.List
+		args.Add(valuesToDictionary(b.config))
+		err := c.rpcWithNoResult(ctx, "core.set_config", args, rencode.Dictionary{})
+		if err != nil {
+			return fmt.Errorf("set config: %w", err)
+		}
+		b.config = map[string]interface{}{}
+	}
+
+	if kind != ChangeLabel && len(b.labels) != 0 {
+		for _, label := range b.labels {
+			var args rencode.List
+			args.Add(label, valuesToDictionary(b.labelOptions[label]))
+			err := c.rpcWithNoResult(ctx, "label.set_options", args, rencode.Dictionary{})
+			if err != nil {
+				return fmt.Errorf("set label %s options: %w", label, err)
+			}
+		}
+		b.labelOptions = map[string]map[string]interface{}{}
+		b.labels = nil
+	}
+
+	return nil
+}
+
+func (c *Client) applyAccountChange(ctx context.Context, sc StateChange) error {
+	var (
+		ok  bool
+		err error
+	)
+	switch sc.Action {
+	case ActionAdd:
+		ok, err = c.rpcWithBoolResult(ctx, "core.create_account", sc.New.(Account).toList())
+	case ActionUpdate:
+		ok, err = c.rpcWithBoolResult(ctx, "core.update_account", sc.New.(Account).toList())
+	case ActionRemove:
+		ok, err = c.rpcWithBoolResult(ctx, "core.remove_account", rencode.NewList(sc.Name))
+	}
+	if err == nil && !ok {
+		err = ErrInvalidReturnValue
+	}
+	return err
+}
+
+// Apply reconciles the daemon to the desired state and returns the applied plan;
+// use Plan to review the changes first.
+func (c *Client) Apply(ctx context.Context, desired *DesiredState) (*StatePlan, error) {
+	plan, err := c.Plan(ctx, desired)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.ApplyPlan(ctx, plan)
+	if err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+// valuesEqual compares normalized values, ignoring the precision lost by floats
+// sent as 32 bits by the daemon.
+func valuesEqual(a, b interface{}) bool {
+	if fa, ok := a.(float64); ok {
+		switch fb := b.(type) {
+		case float64:
+			return float32(fa) == float32(fb)
+		case int64:
+			return fa == float64(fb)
+		}
+	}
+	if ia, ok := a.(int64); ok {
+		if fb, ok := b.(float64); ok {
+			return float64(ia) == fb
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func containsString(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedDifference returns the sorted elements of a which are not in b.
+func sortedDifference(a, b []string) []string {
+	var result []string
+	for _, e := range a {
+		if !containsString(b, e) && !containsString(result, e) {
+			result = append(result, e)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,223 @@
+package deluge
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gdm85/go-rencode"
+)
+
+const testDesiredState = `{
+	"config": {"max_connections_global": 300, "max_upload_speed": -1, "dht": true},
+	"plugins": ["Label", "Scheduler"],
+	"labels": {"movies": {"max_upload_speed": 100, "apply_max": true}, "tv": null},
+	"accounts": [{"username": "alice", "password": "secret", "auth_level": "ADMIN"}],
+	"prune": true
+}`
+
+// fakeDaemonState is a minimal daemon state for the reconciliation tests.
+type fakeDaemonState struct {
+	config   map[string]interface{}
+	plugins  []string
+	labels   map[string]map[string]interface{}
+	accounts map[string]Account
+	calls    []string
+}
+
+func newFakeDaemonState() *fakeDaemonState {
+	return &fakeDaemonState{
+		config:  map[string]interface{}{"max_connections_global": int64(200), "max_upload_speed": float64(-1), "dht": true},
+		plugins: []string{"Label", "Blocklist"},
+		labels: map[string]map[string]interface{}{
+			"movies": {"max_upload_speed": float64(-1), "apply_max": false},
+			"old":    {},
+		},
+		accounts: map[string]Account{
+			"localclient": {Username: "localclient", Password: "x", AuthLevel: AuthLevelAdmin},
+			"alice":       {Username: "alice", Password: "secret", AuthLevel: AuthLevelNormal},
+			"bob":         {Username: "bob", Password: "pw", AuthLevel: AuthLevelNormal},
+		},
+	}
+}
+
+func (s *fakeDaemonState) handle(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+	vals := normalizeRencode(args).([]interface{})
+	switch method {
+	case "core.get_enabled_plugins":
+		return sliceToRencodeList(s.plugins), nil
+	case "core.get_config":
+		return valuesToDictionary(s.config), nil
+	case "label.get_labels":
+		return sliceToRencodeList(sortedKeys(s.labels)), nil
+	case "label.get_options":
+		return valuesToDictionary(s.labels[vals[0].(string)]), nil
+	case "core.get_known_accounts":
+		var list rencode.List
+		for _, name := range sortedKeys(s.accounts) {
+			a := s.accounts[name]
+			var d rencode.Dictionary
+			d.Add("username", a.Username)
+			d.Add("password", a.Password)
+			d.Add("authlevel", string(a.AuthLevel))
+			list.Add(d)
+		}
+		return list, nil
+	}
+
+	s.calls = append(s.calls, method)
+	switch method {
+	case "core.enable_plugin", "core.disable_plugin", "label.add", "label.remove":
+		return nil, nil
+	case "core.set_config":
+		for k, v := range vals[0].(map[string]interface{}) {
+			s.config[k] = v
+		}
+		return nil, nil
+	case "label.set_options":
+		return nil, nil
+	case "core.create_account", "core.update_account", "core.remove_account":
+		return true, nil
+	}
+	return nil, RPCError{ExceptionType: "AttributeError", ExceptionMessage: method}
+}
+
+func TestParseDesiredState(t *testing.T) {
+	t.Parallel()
+
+	ds, err := ParseDesiredState([]byte(testDesiredState), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ds.Config.MaxConnectionsGlobal == nil || *ds.Config.MaxConnectionsGlobal != 300 {
+		t.Errorf("unexpected config %v", ds.Config.MaxConnectionsGlobal)
+	}
+	if !reflect.DeepEqual(ds.Plugins, []string{"Label", "Scheduler"}) {
+		t.Errorf("unexpected plugins %v", ds.Plugins)
+	}
+	if len(ds.Labels) != 2 || ds.Labels["tv"] != nil || *ds.Labels["movies"].MaxUploadSpeed != 100 {
+		t.Errorf("unexpected labels %v", ds.Labels)
+	}
+	if len(ds.Accounts) != 1 || ds.Accounts[0].AuthLevel != AuthLevelAdmin {
+		t.Errorf("unexpected accounts %v", ds.Accounts)
+	}
+	if !ds.Prune {
+		t.Error("expected prune to be set")
+	}
+
+	_, err = ParseDesiredState([]byte(`{"plugin": []}`), nil)
+	if err == nil {
+		t.Error("expected error for unknown key")
+	}
+}
+
+func TestPlan(t *testing.T) {
+	t.Parallel()
+
+	s := newFakeDaemonState()
+	c := newFakeClientV2(s.handle)
+
+	ds, err := ParseDesiredState([]byte(testDesiredState), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := c.Plan(context.Background(), ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `+ plugin Scheduler
+- plugin Blocklist
+~ config max_connections_global: 200 -> 300
+~ label movies apply_max: false -> true
+~ label movies max_upload_speed: -1 -> 100
++ label tv
+- label old
+~ account alice: auth level NORMAL -> ADMIN
+- account bob
+`
+	if plan.String() != expected {
+		t.Errorf("unexpected plan:\n%s", plan)
+	}
+	if len(s.calls) != 0 {
+		t.Errorf("unexpected calls while planning: %v", s.calls)
+	}
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	s := newFakeDaemonState()
+	c := newFakeClientV2(s.handle)
+
+	ds, err := ParseDesiredState([]byte(testDesiredState), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.Apply(context.Background(), ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"core.enable_plugin",
+		"core.disable_plugin",
+		"core.set_config",
+		"label.add",
+		"label.remove",
+		"label.set_options",
+		"core.update_account",
+		"core.remove_account",
+	}
+	if !reflect.DeepEqual(s.calls, expected) {
+		t.Errorf("unexpected calls %v", s.calls)
+	}
+	if s.config["max_connections_global"] != int64(300) {
+		t.Errorf("unexpected config %v", s.config)
+	}
+}
+
+func TestPlanUnknownConfigKey(t *testing.T) {
+	t.Parallel()
+
+	c := newFakeClientV2(newFakeDaemonState().handle)
+
+	ds, err := ParseDesiredState([]byte(`{"config": {"max_conections_global": 300}}`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.Plan(context.Background(), ds)
+	if err == nil || !strings.Contains(err.Error(), "max_conections_global") {
+		t.Errorf("expected unknown key error, got %v", err)
+	}
+}
+
+func TestPlanNoneConfigKey(t *testing.T) {
+	t.Parallel()
+
+	s := newFakeDaemonState()
+	s.config["listen_random_port"] = nil
+	c := newFakeClientV2(s.handle)
+
+	ds, err := ParseDesiredState([]byte(`{"config": {"listen_random_port": 6881}}`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := c.Plan(context.Background(), ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(plan.Changes) != 1 {
+		t.Fatalf("unexpected plan:\n%s", plan)
+	}
+	change := plan.Changes[0]
+	if change.Name != "listen_random_port" || change.Old != nil || change.New != int64(6881) {
+		t.Errorf("unexpected change %+v", change)
+	}
+}
@@ -0,0 +1,198 @@
+// go-libdeluge v0.5.6 - a native deluge RPC client library
+// Copyright (C) 2015~2023 gdm85 - https://github.com/gdm85/go-libdeluge/
+// This program is free software; you can redistribute it and/or
+// modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; either version 2
+// of the License, or (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// You should have received a copy of the GNU General Public License
+// along with this program; if not, write to the Free Software
+// Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+
+package deluge
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// maxBencodeDepth limits nesting to protect against maliciously deep input.
+const maxBencodeDepth = 64
+
+// bencodeDecoder decodes bencoded data as used by .torrent files into
+// int64, []byte, []interface{} and map[string]interface{} values.
+// The raw bytes of the top-level "info" dictionary are recorded, as they
+// are needed to compute the info hash.
+type bencodeDecoder struct {
+	data  []byte
+	pos   int
+	depth int
+
+	infoStart, infoEnd int
+}
+
+func decodeBencode(data []byte) (interface{}, *bencodeDecoder, error) {
+	d := &bencodeDecoder{data: data}
+	v, err := d.decode()
+	if err != nil {
+		return nil, nil, err
+	}
+	if d.pos != len(d.data) {
+		return nil, nil, fmt.Errorf("bencode: %d trailing bytes", len(d.data)-d.pos)
+	}
+	return v, d, nil
+}
+
+func (d *bencodeDecoder) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("bencode: offset %d: %s", d.pos, fmt.Sprintf(format, args...))
+}
+
+func (d *bencodeDecoder) decode() (interface{}, error) {
+	if d.pos >= len(d.data) {
+		return nil, d.errorf("unexpected end of data")
+	}
+
+	switch c := d.data[d.pos]; {
+	case c == 'i':
+		d.pos++
+		end := d.indexFrom('e')
+		if end < 0 {
+			return nil, d.errorf("unterminated integer")
+		}
+		n, err := strconv.ParseInt(string(d.data[d.pos:end]), 10, 64)
+		if err != nil {
+			return nil, d.errorf("invalid integer")
+		}
+		d.pos = end + 1
+		return n, nil
+	case c >= '0' && c <= '9':
+		return d.decodeString()
+	case c == 'l':
+		return d.decodeList()
+	case c == 'd':
+		return d.decodeDict()
+	}
+
+	return nil, d.errorf("unexpected byte %q", d.data[d.pos])
+}
+
+func (d *bencodeDecoder) indexFrom(b byte) int {
+	for i := d.pos; i < len(d.data); i++ {
+		if d.data[i] == b {
+			return i
+		}
+	}
+	return -1
+}
+
+func (d *bencodeDecoder) decodeString() ([]byte, error) {
+	colon := d.indexFrom(':')
+	if colon < 0 {
+		return nil, d.errorf("unterminated string length")
+	}
+	n, err := strconv.Atoi(string(d.data[d.pos:colon]))
+	if err != nil || n < 0 {
+		return nil, d.errorf("invalid string length")
+	}
+	if n > len(d.data)-colon-1 {
+		return nil, d.errorf("string length %d exceeds data", n)
+	}
+	d.pos = colon + 1 + n
+	return d.data[colon+1 : d.pos], nil
+}
+
+func (d *bencodeDecoder) enter() error {
+	d.depth++
+	if d.depth > maxBencodeDepth {
+		return d.errorf("nesting too deep")
+	}
+	d.pos++
+	return nil
+}
+
+func (d *bencodeDecoder) decodeList() ([]interface{}, error) {
+	err := d.enter()
+	if err != nil {
+		return nil, err
+	}
+
+	list := []interface{}{}
+	for {
+		if d.pos >= len(d.data) {
+			return nil, d.errorf("unterminated list")
+		}
+		if d.data[d.pos] == 'e' {
+			d.pos++
+			d.depth--
+			return list, nil
+		}
+		v, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+}
+
+func (d *bencodeDecoder) decodeDict() (map[string]interface{}, error) {
+	err := d.enter()
+	if err != nil {
+		return nil, err
+	}
+
+	dict := map[string]interface{}{}
+	for {
+		if d.pos >= len(d.data) {
+			return nil, d.errorf("unterminated dictionary")
+		}
+		if d.data[d.pos] == 'e' {
+			d.pos++
+			d.depth--
+			return dict, nil
+		}
+		if d.data[d.pos] < '0' || d.data[d.pos] > '9' {
+			return nil, d.errorf("dictionary key is not a string")
+		}
+		key, err := d.decodeString()
+		if err != nil {
+			return nil, err
+		}
+
+		start := d.pos
+		v, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		if d.depth == 1 && string(key) == "info" {
+			d.infoStart, d.infoEnd = start, d.pos
+		}
+		dict[string(key)] = v
+	}
+}
+
+// infoHashV1 returns the hex-encoded SHA-1 of the raw "info" dictionary.
+func (d *bencodeDecoder) infoHashV1() (string, error) {
+	if d.infoEnd == 0 {
+		return "", errors.New("bencode: missing info dictionary")
+	}
+	sum := sha1.Sum(d.data[d.infoStart:d.infoEnd])
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// infoHashV2Truncated returns the hex-encoded SHA-256 of the raw "info" dictionary,
+// truncated to the length of a v1 info hash as done by libtorrent for the id of
+// v2-only torrents.
+func (d *bencodeDecoder) infoHashV2Truncated() (string, error) {
+	if d.infoEnd == 0 {
+		return "", errors.New("bencode: missing info dictionary")
+	}
+	sum := sha256.Sum256(d.data[d.infoStart:d.infoEnd])
+	return hex.EncodeToString(sum[:sha1.Size]), nil
+}
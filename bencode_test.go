@@ -0,0 +1,49 @@
+package deluge
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"testing"
+)
+
+const (
+	testInfoDict = "d6:lengthi1024e4:name8:test.iso12:piece lengthi16384e6:pieces20:aaaaaaaaaaaaaaaaaaaa7:privatei1ee"
+	testMetainfo = "d8:announce23:http://tracker/announce4:info" + testInfoDict + "e"
+)
+
+func TestDecodeBencode(t *testing.T) {
+	t.Parallel()
+
+	v, d, err := decodeBencode([]byte(testMetainfo))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected dictionary, got %T", v)
+	}
+	if string(m["announce"].([]byte)) != "http://tracker/announce" {
+		t.Errorf("unexpected announce %q", m["announce"])
+	}
+
+	sum := sha1.Sum([]byte(testInfoDict))
+	hash, err := d.infoHashV1()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != hex.EncodeToString(sum[:]) {
+		t.Errorf("unexpected info hash %s", hash)
+	}
+}
+
+func TestDecodeBencodeInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, s := range []string{"", "i12", "5:abc", "d3:keye", "l", "di1ei2ee", "i1ei2e", "not a torrent"} {
+		_, _, err := decodeBencode([]byte(s))
+		if err == nil {
+			t.Errorf("%q: expected error", s)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+// go-libdeluge v0.5.6 - a native deluge RPC client library
+// Copyright (C) 2015~2023 gdm85 - https://github.com/gdm85/go-libdeluge/
+// This program is free software; you can redistribute it and/or
+// modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; either version 2
+// of the License, or (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// You should have received a copy of the GNU General Public License
+// along with this program; if not, write to the Free Software
+// Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+
+package deluge
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/gdm85/go-rencode"
+)
+
+// CoreConfig is the daemon configuration stored in core.conf; nil fields are
+// not set by SetConfig and are left nil by GetConfig when missing from the daemon.
+// Valid keys for v2: https://github.com/deluge-torrent/deluge/blob/deluge-2.0.3/deluge/core/preferencesmanager.py#L42-L134
+// Valid keys for v1: https://github.com/deluge-torrent/deluge/blob/1.3-stable/deluge/core/preferencesmanager.py#L50-L146
+type CoreConfig struct {
+	SendInfo                   *bool
+	InfoSent                   *float64
+	DaemonPort                 *int
+	AllowRemote                *bool
+	DownloadLocation           *string
+	ListenPorts                []int
+	ListenInterface            *string
+	RandomPort                 *bool
+	OutgoingPorts              []int
+	RandomOutgoingPorts        *bool
+	CopyTorrentFile            *bool
+	DelCopyTorrentFile         *bool
+	TorrentfilesLocation       *string
+	PluginsLocation            *string
+	PrioritizeFirstLastPieces  *bool
+	Dht                        *bool
+	Upnp                       *bool
+	Natpmp                     *bool
+	Utpex                      *bool
+	Lsd                        *bool
+	EncInPolicy                *int
+	EncOutPolicy               *int
+	EncLevel                   *int
+	MaxConnectionsGlobal       *int
+	MaxUploadSpeed             *float32
+	MaxDownloadSpeed           *float32
+	MaxUploadSlotsGlobal       *int
+	MaxHalfOpenConnections     *int
+	MaxConnectionsPerSecond    *int
+	IgnoreLimitsOnLocalNetwork *bool
+	MaxConnectionsPerTorrent   *int
+	MaxUploadSlotsPerTorrent   *int
+	MaxUploadSpeedPerTorrent   *float32
+	MaxDownloadSpeedPerTorrent *float32
+	EnabledPlugins             []string
+	AddPaused                  *bool
+	MaxActiveSeeding           *int
+	MaxActiveDownloading       *int
+	MaxActiveLimit             *int
+	DontCountSlowTorrents      *bool
+	QueueNewToTop              *bool
+	StopSeedAtRatio            *bool
+	RemoveSeedAtRatio          *bool
+	StopSeedRatio              *float32
+	ShareRatioLimit            *float32
+	SeedTimeRatioLimit         *float32
+	SeedTimeLimit              *int
+	AutoManaged                *bool
+	MoveCompleted              *bool
+	MoveCompletedPath          *string
+	NewReleaseCheck            *bool
+	PeerTos                    *string
+	RateLimitIpOverhead        *bool
+	GeoipDbLocation            *string
+	CacheSize                  *int
+	CacheExpiry                *int
+
+	PreAllocateStorage        *bool    `rencode:"v2only"`
+	ListenRandomPort          *int     `rencode:"v2only"`
+	ListenUseSysPort          *bool    `rencode:"v2only"`
+	ListenReusePort           *bool    `rencode:"v2only"`
+	OutgoingInterface         *string  `rencode:"v2only"`
+	SequentialDownload        *bool    `rencode:"v2only"`
+	MoveCompletedPathsList    []string `rencode:"v2only"`
+	DownloadLocationPathsList []string `rencode:"v2only"`
+	AutoManagePreferSeeds     *bool    `rencode:"v2only"`
+	Shared                    *bool    `rencode:"v2only"`
+	SuperSeeding              *bool    `rencode:"v2only"`
+
+	CompactAllocation *bool   `rencode:"v1only"`
+	EncPreferRc4      *bool   `rencode:"v1only"`
+	AutoaddEnable     *bool   `rencode:"v1only"`
+	AutoaddLocation   *string `rencode:"v1only"`
+
+	// Extra holds the keys without a field, e.g. those added by newer daemon versions.
+	Extra map[string]interface{}
+}
+
+// versionTagExcluded returns the tag of the fields not valid for the daemon version.
+func versionTagExcluded(v2daemon bool) string {
+	if v2daemon {
+		return "v1only"
+	}
+	return "v2only"
+}
+
+func (cfg *CoreConfig) toDictionary(v2daemon bool) (rencode.Dictionary, error) {
+	var dict rencode.Dictionary
+	if cfg == nil {
+		return dict, nil
+	}
+
+	excluded := versionTagExcluded(v2daemon)
+	v := reflect.ValueOf(*cfg)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Name == "Extra" || v.Field(i).IsNil() {
+			continue
+		}
+		name := rencode.ToSnakeCase(f.Name)
+		if f.Tag.Get("rencode") == excluded {
+			return dict, fmt.Errorf("config key %q is not supported by this daemon version", name)
+		}
+		dict.Add(name, toRencode(v.Field(i)))
+	}
+
+	keys := make([]string, 0, len(cfg.Extra))
+	for k := range cfg.Extra {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		dict.Add(k, toRencode(reflect.ValueOf(cfg.Extra[k])))
+	}
+
+	return dict, nil
+}
+
+// fromMap sets the fields from normalized values, see normalizeRencode;
+// keys without a field are stored in Extra.
+func (cfg *CoreConfig) fromMap(m map[string]interface{}) error {
+	fields := map[string]int{}
+	t := reflect.TypeOf(*cfg)
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Name != "Extra" {
+			fields[rencode.ToSnakeCase(t.Field(i).Name)] = i
+		}
+	}
+
+	v := reflect.ValueOf(cfg).Elem()
+	for key, value := range m {
+		i, ok := fields[key]
+		if !ok {
+			if cfg.Extra == nil {
+				cfg.Extra = map[string]interface{}{}
+			}
+			cfg.Extra[key] = value
+			continue
+		}
+		err := assignRencode(value, v.Field(i))
+		if err != nil {
+			return fmt.Errorf("config key %q: %v", key, err)
+		}
+	}
+
+	return nil
+}
+
+// values returns the normalized values of the non-nil fields and of the Extra keys.
+func (cfg *CoreConfig) values() map[string]interface{} {
+	result := map[string]interface{}{}
+	v := reflect.ValueOf(*cfg)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Name == "Extra" || v.Field(i).IsNil() {
+			continue
+		}
+		result[rencode.ToSnakeCase(t.Field(i).Name)] = normalizeRencode(toRencode(v.Field(i)))
+	}
+	for k, e := range cfg.Extra {
+		result[k] = normalizeRencode(toRencode(reflect.ValueOf(e)))
+	}
+	return result
+}
+
+// GetConfig returns the daemon configuration.
+func (c *Client) GetConfig(ctx context.Context) (*CoreConfig, error) {
+	m, err := c.getConfigMap(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg CoreConfig
+	err = cfg.fromMap(m)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// getConfigMap returns the normalized daemon configuration, including the keys set to None.
+func (c *Client) getConfigMap(ctx context.Context) (map[string]interface{}, error) {
+	resp, err := c.rpc(ctx, "core.get_config", rencode.List{}, rencode.Dictionary{})
+	if err != nil {
+		return nil, err
+	}
+	if resp.IsError() {
+		return nil, resp.RPCError
+	}
+
+	var rd rencode.Dictionary
+	err = resp.returnValue.Scan(&rd)
+	if err != nil {
+		return nil, err
+	}
+
+	return normalizeRencode(rd).(map[string]interface{}), nil
+}
+
+// GetConfigValue returns the value of a single configuration key, converted to
+// string, int64, float64, bool, []interface{} or map[string]interface{}.
+func (c *Client) GetConfigValue(ctx context.Context, key string) (interface{}, error) {
+	var args rencode.List
+	args.Add(key)
+
+	resp, err := c.rpc(ctx, "core.get_config_value", args, rencode.Dictionary{})
+	if err != nil {
+		return nil, err
+	}
+	if resp.IsError() {
+		return nil, resp.RPCError
+	}
+
+	vals := resp.returnValue.Values()
+	if len(vals) != 1 {
+		return nil, ErrInvalidReturnValue
+	}
+
+	return normalizeRencode(vals[0]), nil
+}
+
+// GetConfigValues returns the values of the given configuration keys, see GetConfigValue.
+func (c *Client) GetConfigValues(ctx context.Context, keys ...string) (map[string]interface{}, error) {
+	var args rencode.List
+	args.Add(sliceToRencodeList(keys))
+
+	resp, err := c.rpc(ctx, "core.get_config_values", args, rencode.Dictionary{})
+	if err != nil {
+		return nil, err
+	}
+	if resp.IsError() {
+		return nil, resp.RPCError
+	}
+
+	var rd rencode.Dictionary
+	err = resp.returnValue.Scan(&rd)
+	if err != nil {
+		return nil, err
+	}
+
+	return normalizeRencode(rd).(map[string]interface{}), nil
+}
+
+// SetConfig sets the non-nil fields of the configuration and the Extra keys;
+// an error is returned for fields not supported by the daemon version.
+func (c *Client) SetConfig(ctx context.Context, cfg *CoreConfig) error {
+	dict, err := cfg.toDictionary(c.v2daemon)
+	if err != nil {
+		return err
+	}
+	if dict.Length() == 0 {
+		return nil
+	}
+
+	var args rencode.List
+	args.Add(dict)
+
+	return c.rpcWithNoResult(ctx, "core.set_config", args, rencode.Dictionary{})
+}
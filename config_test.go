@@ -0,0 +1,140 @@
+package deluge
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/gdm85/go-rencode"
+)
+
+func TestCoreConfigKeys(t *testing.T) {
+	t.Parallel()
+
+	// field names must map to the exact core.conf keys
+	expected := []string{"enc_prefer_rc4", "rate_limit_ip_overhead", "geoip_db_location", "torrentfiles_location", "max_download_speed_per_torrent"}
+	fields := map[string]bool{}
+	typ := reflect.TypeOf(CoreConfig{})
+	for i := 0; i < typ.NumField(); i++ {
+		fields[rencode.ToSnakeCase(typ.Field(i).Name)] = true
+	}
+	for _, key := range expected {
+		if !fields[key] {
+			t.Errorf("missing field for key %q", key)
+		}
+	}
+}
+
+func TestGetConfig(t *testing.T) {
+	t.Parallel()
+
+	c := newFakeClientV2(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		var d rencode.Dictionary
+		d.Add("max_upload_speed", -1)
+		d.Add("max_download_speed", float32(512.5))
+		d.Add("listen_ports", rencode.NewList(6881, 6891))
+		d.Add("enabled_plugins", rencode.NewList("Label"))
+		d.Add("dht", true)
+		d.Add("listen_random_port", nil)
+		d.Add("path_chooser_max_popup_rows", 20)
+		return d, nil
+	})
+
+	cfg, err := c.GetConfig(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MaxUploadSpeed == nil || *cfg.MaxUploadSpeed != -1 {
+		t.Errorf("unexpected max upload speed %v", cfg.MaxUploadSpeed)
+	}
+	if cfg.MaxDownloadSpeed == nil || *cfg.MaxDownloadSpeed != 512.5 {
+		t.Errorf("unexpected max download speed %v", cfg.MaxDownloadSpeed)
+	}
+	if !reflect.DeepEqual(cfg.ListenPorts, []int{6881, 6891}) {
+		t.Errorf("unexpected listen ports %v", cfg.ListenPorts)
+	}
+	if !reflect.DeepEqual(cfg.EnabledPlugins, []string{"Label"}) {
+		t.Errorf("unexpected enabled plugins %v", cfg.EnabledPlugins)
+	}
+	if cfg.Dht == nil || !*cfg.Dht {
+		t.Error("expected DHT to be enabled")
+	}
+	if cfg.ListenRandomPort != nil {
+		t.Errorf("unexpected listen random port %v", *cfg.ListenRandomPort)
+	}
+	if cfg.CacheSize != nil {
+		t.Error("expected missing key to be nil")
+	}
+	if cfg.Extra["path_chooser_max_popup_rows"] != int64(20) {
+		t.Errorf("unexpected extra keys %v", cfg.Extra)
+	}
+}
+
+func TestSetConfig(t *testing.T) {
+	t.Parallel()
+
+	var sent map[string]interface{}
+	c := newFakeClientV2(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		if method != "core.set_config" {
+			return nil, RPCError{ExceptionType: "AttributeError", ExceptionMessage: method}
+		}
+		sent = normalizeRencode(args.Values()[0]).(map[string]interface{})
+		return nil, nil
+	})
+
+	maxConns := 300
+	seq := true
+	err := c.SetConfig(context.Background(), &CoreConfig{
+		MaxConnectionsGlobal: &maxConns,
+		SequentialDownload:   &seq,
+		ListenPorts:          []int{6881, 6881},
+		Extra:                map[string]interface{}{"new_key": "value"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"max_connections_global": int64(300),
+		"sequential_download":    true,
+		"listen_ports":           []interface{}{int64(6881), int64(6881)},
+		"new_key":                "value",
+	}
+	if !reflect.DeepEqual(sent, expected) {
+		t.Errorf("unexpected config sent %v", sent)
+	}
+}
+
+func TestSetConfigVersionMismatch(t *testing.T) {
+	t.Parallel()
+
+	c := newFakeClient(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		t.Errorf("unexpected call to %s", method)
+		return nil, nil
+	})
+
+	seq := true
+	err := c.SetConfig(context.Background(), &CoreConfig{SequentialDownload: &seq})
+	if err == nil {
+		t.Error("expected error for v2-only key on v1 daemon")
+	}
+}
+
+func TestGetConfigValues(t *testing.T) {
+	t.Parallel()
+
+	c := newFakeClient(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		var d rencode.Dictionary
+		d.Add("download_location", "/data")
+		d.Add("max_active_limit", 8)
+		return d, nil
+	})
+
+	values, err := c.GetConfigValues(context.Background(), "download_location", "max_active_limit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values["download_location"] != "/data" || values["max_active_limit"] != int64(8) {
+		t.Errorf("unexpected values %v", values)
+	}
+}
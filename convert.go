@@ -0,0 +1,294 @@
+// go-libdeluge v0.5.6 - a native deluge RPC client library
+// Copyright (C) 2015~2023 gdm85 - https://github.com/gdm85/go-libdeluge/
+// This program is free software; you can redistribute it and/or
+// modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; either version 2
+// of the License, or (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// You should have received a copy of the GNU General Public License
+// along with this program; if not, write to the Free Software
+// Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+
+package deluge
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+
+	"github.com/gdm85/go-rencode"
+)
+
+// The conversions in this file are more lenient than the rencode ones: they accept
+// any integer or float for numeric fields, since values read from Deluge configuration
+// files do not have a stable type (e.g. -1 instead of -1.0), and ignore unknown
+// dictionary keys when decoding into a struct.
+// Values decoded by encoding/json or YAML libraries are accepted as well.
+
+// normalizeRencode converts a decoded rencode value into plain Go values:
+// strings, int64, float64, bool, []interface{} and map[string]interface{}.
+func normalizeRencode(v interface{}) interface{} {
+	switch v := v.(type) {
+	case []byte:
+		return string(v)
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case int:
+		return int64(v)
+	case float32:
+		return float64(v)
+	case big.Int:
+		return v.String()
+	case rencode.List:
+		result := make([]interface{}, v.Length())
+		for i, e := range v.Values() {
+			result[i] = normalizeRencode(e)
+		}
+		return result
+	case rencode.Dictionary:
+		result := make(map[string]interface{}, v.Length())
+		keys := v.Keys()
+		for i, e := range v.Values() {
+			result[fmt.Sprint(normalizeRencode(keys[i]))] = normalizeRencode(e)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, e := range v {
+			result[i] = normalizeRencode(e)
+		}
+		return result
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			result[k] = normalizeRencode(e)
+		}
+		return result
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			result[fmt.Sprint(k)] = normalizeRencode(e)
+		}
+		return result
+	}
+	return v
+}
+
+// assignRencode converts a decoded rencode value into dst; dictionary keys are
+// matched to struct fields with rencode.ToSnakeCase.
+func assignRencode(src interface{}, dst reflect.Value) error {
+	src = normalizeRencode(src)
+
+	if dst.Kind() == reflect.Ptr {
+		if src == nil {
+			dst.Set(reflect.Zero(dst.Type()))
+			return nil
+		}
+		v := reflect.New(dst.Type().Elem())
+		err := assignRencode(src, v.Elem())
+		if err != nil {
+			return err
+		}
+		dst.Set(v)
+		return nil
+	}
+	if src == nil {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+
+	mismatch := func() error {
+		return fmt.Errorf("cannot convert %T to %v", src, dst.Type())
+	}
+
+	switch dst.Kind() {
+	case reflect.Interface:
+		dst.Set(reflect.ValueOf(src))
+	case reflect.Bool:
+		switch s := src.(type) {
+		case bool:
+			dst.SetBool(s)
+		case int64:
+			dst.SetBool(s != 0)
+		default:
+			return mismatch()
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		switch s := src.(type) {
+		case int64:
+			n = s
+		case float64:
+			if s != math.Trunc(s) {
+				return mismatch()
+			}
+			n = int64(s)
+		case bool:
+			if s {
+				n = 1
+			}
+		default:
+			return mismatch()
+		}
+		if dst.OverflowInt(n) {
+			return fmt.Errorf("value %d overflows %v", n, dst.Type())
+		}
+		dst.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s, ok := src.(int64)
+		if !ok || s < 0 || dst.OverflowUint(uint64(s)) {
+			return mismatch()
+		}
+		dst.SetUint(uint64(s))
+	case reflect.Float32, reflect.Float64:
+		switch s := src.(type) {
+		case int64:
+			dst.SetFloat(float64(s))
+		case float64:
+			dst.SetFloat(s)
+		default:
+			return mismatch()
+		}
+	case reflect.String:
+		s, ok := src.(string)
+		if !ok {
+			return mismatch()
+		}
+		dst.SetString(s)
+	case reflect.Slice:
+		s, ok := src.([]interface{})
+		if !ok {
+			return mismatch()
+		}
+		result := reflect.MakeSlice(dst.Type(), len(s), len(s))
+		for i, e := range s {
+			err := assignRencode(e, result.Index(i))
+			if err != nil {
+				return fmt.Errorf("index %d: %v", i, err)
+			}
+		}
+		dst.Set(result)
+	case reflect.Map:
+		s, ok := src.(map[string]interface{})
+		if !ok || dst.Type().Key().Kind() != reflect.String {
+			return mismatch()
+		}
+		result := reflect.MakeMapWithSize(dst.Type(), len(s))
+		for k, e := range s {
+			v := reflect.New(dst.Type().Elem()).Elem()
+			err := assignRencode(e, v)
+			if err != nil {
+				return fmt.Errorf("key %q: %v", k, err)
+			}
+			result.SetMapIndex(reflect.ValueOf(k).Convert(dst.Type().Key()), v)
+		}
+		dst.Set(result)
+	case reflect.Struct:
+		s, ok := src.(map[string]interface{})
+		if !ok {
+			return mismatch()
+		}
+		t := dst.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			v, ok := s[rencode.ToSnakeCase(f.Name)]
+			if !ok {
+				continue
+			}
+			err := assignRencode(v, dst.Field(i))
+			if err != nil {
+				return fmt.Errorf("field %q: %v", f.Name, err)
+			}
+		}
+	default:
+		return mismatch()
+	}
+
+	return nil
+}
+
+// toRencode converts a Go value into a value the rencode encoder can handle;
+// nil pointers and unset interfaces become None.
+func toRencode(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return toRencode(v.Elem())
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint())
+	case reflect.Float32:
+		return float32(v.Float())
+	case reflect.Float64:
+		return v.Float()
+	case reflect.String:
+		return v.String()
+	case reflect.Slice, reflect.Array:
+		var list rencode.List
+		for i := 0; i < v.Len(); i++ {
+			list.Add(toRencode(v.Index(i)))
+		}
+		return list
+	case reflect.Map:
+		var dict rencode.Dictionary
+		keys := v.MapKeys()
+		sortValues(keys)
+		for _, k := range keys {
+			dict.Add(fmt.Sprint(k.Interface()), toRencode(v.MapIndex(k)))
+		}
+		return dict
+	case reflect.Struct:
+		var dict rencode.Dictionary
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			dict.Add(rencode.ToSnakeCase(f.Name), toRencode(v.Field(i)))
+		}
+		return dict
+	}
+	return nil
+}
+
+// sortValues sorts map keys by their string representation, for a stable encoding.
+func sortValues(keys []reflect.Value) {
+	for i := 1; i < len(keys); i++ {
+		for j := i; j > 0 && fmt.Sprint(keys[j].Interface()) < fmt.Sprint(keys[j-1].Interface()); j-- {
+			keys[j], keys[j-1] = keys[j-1], keys[j]
+		}
+	}
+}
+
+// valuesToDictionary converts normalized values into a dictionary with sorted keys.
+func valuesToDictionary(m map[string]interface{}) rencode.Dictionary {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var dict rencode.Dictionary
+	for _, k := range keys {
+		dict.Add(k, toRencode(reflect.ValueOf(m[k])))
+	}
+	return dict
+}
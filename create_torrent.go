@@ -0,0 +1,166 @@
+// go-libdeluge v0.5.6 - a native deluge RPC client library
+// Copyright (C) 2015~2023 gdm85 - https://github.com/gdm85/go-libdeluge/
+// This program is free software; you can redistribute it and/or
+// modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; either version 2
+// of the License, or (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// You should have received a copy of the GNU General Public License
+// along with this program; if not, write to the Free Software
+// Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+
+package deluge
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/gdm85/go-rencode"
+)
+
+// minPieceLength is the smallest piece length accepted by libtorrent.
+const minPieceLength = 16 * 1024
+
+// CreateTorrentRequest describes a torrent to be created by the daemon from
+// data already present on its filesystem.
+type CreateTorrentRequest struct {
+	// Path is the file or folder on the daemon host to create the torrent from.
+	Path string
+	// Trackers are grouped by tier in the announce list; the first tracker of the
+	// lowest tier is used as the primary announce URL.
+	Trackers []Tracker
+	// PieceLength in bytes, must be a power of 2 and at least 16 KiB.
+	PieceLength int
+	Comment     string
+	Private     bool
+	WebSeeds    []string
+	CreatedBy   string
+	// Target is the path on the daemon host where the .torrent file is written;
+	// when empty the daemon picks a default. Deluge v2.0 requires it to add
+	// the torrent to the session.
+	Target string
+	// AddToSession adds the created torrent to the session for seeding.
+	AddToSession bool
+}
+
+// CreatedTorrent is the result of a torrent creation; all the fields are only set
+// by daemons which return the created torrent (v2.1+).
+type CreatedTorrent struct {
+	FileName string
+	// Metainfo is the content of the .torrent file.
+	Metainfo []byte
+	// Hash is the v1 info hash.
+	Hash string
+}
+
+func (r CreateTorrentRequest) toList() (rencode.List, error) {
+	var args rencode.List
+	if r.Path == "" {
+		return args, errors.New("missing path")
+	}
+	if r.PieceLength < minPieceLength || r.PieceLength&(r.PieceLength-1) != 0 {
+		return args, fmt.Errorf("invalid piece length %d", r.PieceLength)
+	}
+
+	trackers := make([]Tracker, len(r.Trackers))
+	copy(trackers, r.Trackers)
+	sort.SliceStable(trackers, func(i, j int) bool {
+		return trackers[i].Tier < trackers[j].Tier
+	})
+
+	var primary string
+	var tiers rencode.List
+	var tier rencode.List
+	for i, t := range trackers {
+		if i == 0 {
+			primary = t.URL
+		} else if t.Tier != trackers[i-1].Tier {
+			tiers.Add(tier)
+			tier = rencode.List{}
+		}
+		tier.Add(t.URL)
+	}
+	if tier.Length() != 0 {
+		tiers.Add(tier)
+	}
+
+	args.Add(r.Path, primary, r.PieceLength, optionalString(r.Comment), optionalString(r.Target))
+	if len(r.WebSeeds) != 0 {
+		args.Add(sliceToRencodeList(r.WebSeeds))
+	} else {
+		args.Add(nil)
+	}
+	args.Add(r.Private, optionalString(r.CreatedBy))
+	if tiers.Length() != 0 {
+		args.Add(tiers)
+	} else {
+		args.Add(nil)
+	}
+	args.Add(r.AddToSession)
+
+	return args, nil
+}
+
+// optionalString returns nil for an empty string, so that the daemon uses its default.
+func optionalString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// CreateTorrent creates a torrent on the daemon host, optionally adding it to the session.
+// Daemons before v2.1 do not return the created torrent, in which case the returned
+// CreatedTorrent is empty.
+func (c *Client) CreateTorrent(ctx context.Context, req CreateTorrentRequest) (*CreatedTorrent, error) {
+	args, err := req.toList()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.rpc(ctx, "core.create_torrent", args, rencode.Dictionary{})
+	if err != nil {
+		return nil, err
+	}
+	if resp.IsError() {
+		return nil, resp.RPCError
+	}
+
+	var result CreatedTorrent
+	vals := resp.returnValue.Values()
+	if len(vals) == 0 || vals[0] == nil {
+		return &result, nil
+	}
+
+	// v2.1+ returns a (filename, base64 filedump) tuple
+	tuple, ok := vals[0].(rencode.List)
+	if !ok {
+		return nil, ErrInvalidReturnValue
+	}
+	var dump string
+	err = tuple.Scan(&result.FileName, &dump)
+	if err != nil {
+		return nil, err
+	}
+	result.Metainfo, err = base64.StdEncoding.DecodeString(dump)
+	if err != nil {
+		return nil, err
+	}
+
+	_, d, err := decodeBencode(result.Metainfo)
+	if err != nil {
+		return nil, err
+	}
+	result.Hash, err = d.infoHashV1()
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
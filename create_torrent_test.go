@@ -0,0 +1,62 @@
+package deluge
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/gdm85/go-rencode"
+)
+
+func TestCreateTorrent(t *testing.T) {
+	t.Parallel()
+
+	req := CreateTorrentRequest{
+		Path: "/data/release",
+		Trackers: []Tracker{
+			{URL: "http://backup/announce", Tier: 1},
+			{URL: "http://primary/announce", Tier: 0},
+		},
+		PieceLength:  1 << 20,
+		Private:      true,
+		AddToSession: true,
+	}
+
+	c := newFakeClientV2(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		if method != "core.create_torrent" {
+			return nil, RPCError{ExceptionType: "AttributeError", ExceptionMessage: method}
+		}
+
+		var (
+			path, tracker string
+			pieceLength   int
+		)
+		err := args.Scan(&path, &tracker, &pieceLength)
+		if err != nil {
+			return nil, err
+		}
+		if tracker != "http://primary/announce" {
+			t.Errorf("unexpected primary tracker %q", tracker)
+		}
+		tiers := args.Values()[8].(rencode.List)
+		if tiers.Length() != 2 {
+			t.Errorf("expected 2 tiers, got %d", tiers.Length())
+		}
+
+		return rencode.NewList("release.torrent", base64.StdEncoding.EncodeToString([]byte(testMetainfo))), nil
+	})
+
+	created, err := c.CreateTorrent(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.FileName != "release.torrent" || len(created.Hash) != 40 {
+		t.Errorf("unexpected result %+v", created)
+	}
+
+	req.PieceLength = 1000
+	_, err = c.CreateTorrent(context.Background(), req)
+	if err == nil {
+		t.Error("expected invalid piece length error")
+	}
+}
@@ -0,0 +1,154 @@
+// go-libdeluge v0.5.6 - a native deluge RPC client library
+// Copyright (C) 2015~2023 gdm85 - https://github.com/gdm85/go-libdeluge/
+// This program is free software; you can redistribute it and/or
+// modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; either version 2
+// of the License, or (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// You should have received a copy of the GNU General Public License
+// along with this program; if not, write to the Free Software
+// Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+
+package deluge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/gdm85/go-rencode"
+)
+
+// restartPollInterval is the interval used by RestartAndWait to check the daemon listener.
+const restartPollInterval = time.Millisecond * 500
+
+// RestartFunc starts the daemon process again, once the previous one has stopped listening.
+type RestartFunc func(ctx context.Context) error
+
+// GetVersion returns the version of the daemon, e.g. "2.1.1".
+// On v1 daemons this is the same as DaemonVersion.
+func (c *Client) GetVersion(ctx context.Context) (string, error) {
+	if !c.v2daemon {
+		return c.DaemonVersion(ctx)
+	}
+
+	resp, err := c.rpc(ctx, "daemon.get_version", rencode.List{}, rencode.Dictionary{})
+	if err != nil {
+		return "", err
+	}
+	if resp.IsError() {
+		return "", resp.RPCError
+	}
+
+	var version string
+	err = resp.returnValue.Scan(&version)
+	if err != nil {
+		return "", err
+	}
+
+	return version, nil
+}
+
+// Shutdown stops the daemon; the client must be connected again once the daemon is restarted.
+// The connection being closed or reset before the response is received is not an error.
+func (c *Client) Shutdown(ctx context.Context) error {
+	err := c.rpcWithNoResult(ctx, "daemon.shutdown", rencode.List{}, rencode.Dictionary{})
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) {
+		return nil
+	}
+	return err
+}
+
+// RestartAndWait shuts the daemon down, waits for its listener to go away and calls restart
+// to start it again; restart can be nil when the daemon is restarted by a supervisor.
+// It then connects again until the daemon accepts the login or the context is done,
+// and returns the version of the restarted daemon.
+func (c *Client) RestartAndWait(ctx context.Context, restart RestartFunc) (string, error) {
+	err := c.Shutdown(ctx)
+	if err != nil {
+		return "", err
+	}
+	_ = c.Close()
+
+	err = c.waitListenerGone(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if restart != nil {
+		err = restart(ctx)
+		if err != nil {
+			return "", fmt.Errorf("restart daemon: %w", err)
+		}
+	}
+
+	err = c.reconnect(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	version, err := c.GetVersion(ctx)
+	if err != nil {
+		return "", err
+	}
+	if version == "" {
+		return "", ErrInvalidReturnValue
+	}
+
+	return version, nil
+}
+
+func (c *Client) address() string {
+	return fmt.Sprintf("%s:%d", c.settings.Hostname, c.settings.Port)
+}
+
+// waitListenerGone polls the daemon address until connections are refused;
+// other dial errors, like timeouts of a busy daemon, are retried.
+func (c *Client) waitListenerGone(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: restartPollInterval}
+
+	ticker := time.NewTicker(restartPollInterval)
+	defer ticker.Stop()
+
+	for {
+		conn, err := dialer.DialContext(ctx, "tcp", c.address())
+		if err == nil {
+			_ = conn.Close()
+		} else if errors.Is(err, syscall.ECONNREFUSED) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("daemon still listening on %s: %w", c.address(), ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// reconnect calls Connect until it succeeds or the context is done.
+func (c *Client) reconnect(ctx context.Context) error {
+	ticker := time.NewTicker(restartPollInterval)
+	defer ticker.Stop()
+
+	for {
+		err := c.Connect(ctx)
+		if err == nil {
+			return nil
+		}
+		_ = c.Close()
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("reconnect to %s: %v: %w", c.address(), err, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
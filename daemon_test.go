@@ -0,0 +1,170 @@
+package deluge
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gdm85/go-rencode"
+)
+
+func TestGetVersion(t *testing.T) {
+	t.Parallel()
+
+	c := newFakeClientV2(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		if method != "daemon.get_version" {
+			t.Fatalf("unexpected method %q", method)
+		}
+		return "2.1.1", nil
+	})
+
+	version, err := c.GetVersion(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != "2.1.1" {
+		t.Errorf("unexpected version %q", version)
+	}
+}
+
+func TestGetVersionV1(t *testing.T) {
+	t.Parallel()
+
+	c := newFakeClient(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		if method != "daemon.info" {
+			t.Fatalf("unexpected method %q", method)
+		}
+		return "1.3.15", nil
+	})
+
+	version, err := c.GetVersion(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != "1.3.15" {
+		t.Errorf("unexpected version %q", version)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	t.Parallel()
+
+	c := newFakeClientV2(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		if method != "daemon.shutdown" {
+			t.Fatalf("unexpected method %q", method)
+		}
+		return nil, nil
+	})
+
+	err := c.Shutdown(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestShutdownConnectionDropped(t *testing.T) {
+	t.Parallel()
+
+	c := newFakeClientV2(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		return nil, io.EOF
+	})
+
+	err := c.Shutdown(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestShutdownConnectionReset(t *testing.T) {
+	t.Parallel()
+
+	c := newFakeClientV2(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		return nil, &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}
+	})
+
+	err := c.Shutdown(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestShutdownError(t *testing.T) {
+	t.Parallel()
+
+	c := newFakeClientV2(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		return nil, RPCError{ExceptionType: "NotAuthorizedError"}
+	})
+
+	err := c.Shutdown(context.Background())
+	var rpcErr RPCError
+	if !errors.As(err, &rpcErr) {
+		t.Errorf("expected RPCError, got %v", err)
+	}
+}
+
+func TestRestartAndWaitCallsRestart(t *testing.T) {
+	t.Parallel()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	addr := l.Addr().(*net.TCPAddr)
+
+	c := newFakeClientV2(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		if method != "daemon.shutdown" {
+			t.Fatalf("unexpected method %q", method)
+		}
+		l.Close()
+		return nil, nil
+	})
+	c.settings.Hostname = addr.IP.String()
+	c.settings.Port = uint(addr.Port)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+	defer cancel()
+
+	restarted := false
+	_, err = c.RestartAndWait(ctx, func(context.Context) error {
+		restarted = true
+		return nil
+	})
+	if !restarted {
+		t.Error("restart was not called")
+	}
+	// nothing listens after the restart
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestRestartAndWaitRestartError(t *testing.T) {
+	t.Parallel()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().(*net.TCPAddr)
+	l.Close()
+
+	c := newFakeClientV2(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		return nil, nil
+	})
+	c.settings.Hostname = addr.IP.String()
+	c.settings.Port = uint(addr.Port)
+
+	failure := errors.New("exec failed")
+	_, err = c.RestartAndWait(context.Background(), func(context.Context) error {
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Errorf("expected restart failure, got %v", err)
+	}
+}
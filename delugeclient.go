@@ -28,6 +28,7 @@ import (
 	"log"
 	"math"
 	"net"
+	"sync"
 	"time"
 
 	"github.com/gdm85/go-rencode"
@@ -68,29 +69,86 @@ type DelugeClient interface {
 	DaemonLogin(ctx context.Context) error
 	MethodsList(ctx context.Context) ([]string, error)
 	DaemonVersion(ctx context.Context) (string, error)
+	GetVersion(ctx context.Context) (string, error)
+	Shutdown(ctx context.Context) error
+	RestartAndWait(ctx context.Context, restart RestartFunc) (string, error)
 	GetFreeSpace(context.Context, string) (int64, error)
 	GetLibtorrentVersion(ctx context.Context) (string, error)
 	AddTorrentMagnet(ctx context.Context, magnetURI string, options *Options) (string, error)
+	AddMagnet(ctx context.Context, magnet *Magnet, options *Options) (string, error)
 	AddTorrentURL(ctx context.Context, url string, options *Options) (string, error)
 	AddTorrentFile(ctx context.Context, fileName, fileContentBase64 string, options *Options) (string, error)
 	RemoveTorrents(ctx context.Context, ids []string, rmFiles bool) ([]TorrentError, error)
 	RemoveTorrent(ctx context.Context, id string, rmFiles bool) (bool, error)
 	PauseTorrents(ctx context.Context, ids ...string) error
 	ResumeTorrents(ctx context.Context, ids ...string) error
-	TorrentsStatus(ctx context.Context, state TorrentState, ids []string) (map[string]*TorrentStatus, error)
-	TorrentStatus(ctx context.Context, id string) (*TorrentStatus, error)
+	TorrentsStatus(ctx context.Context, state TorrentState, ids []string, opts ...StatusOption) (map[string]*TorrentStatus, error)
+	TorrentsStatusFiltered(ctx context.Context, filter TorrentFilter, opts ...StatusOption) (map[string]*TorrentStatus, error)
+	TorrentsStatusMap(ctx context.Context, filter TorrentFilter, keys ...string) (map[string]map[string]interface{}, error)
+	TorrentStatus(ctx context.Context, id string, opts ...StatusOption) (*TorrentStatus, error)
 	MoveStorage(ctx context.Context, torrentIDs []string, dest string) error
+	MoveStorageAndWait(ctx context.Context, ids []string, dest string, interval time.Duration, resume bool) ([]MoveResult, error)
+	WaitMoveStorage(ctx context.Context, ids []string, dest string, interval time.Duration) ([]MoveResult, error)
 	SetTorrentTracker(ctx context.Context, id, tracker string) error
+	GetTrackers(ctx context.Context, id string) ([]Tracker, error)
+	SetTrackers(ctx context.Context, id string, trackers []Tracker) error
+	AddTrackers(ctx context.Context, id string, trackers ...Tracker) error
+	RemoveTrackers(ctx context.Context, id string, urls ...string) error
 	SetTorrentOptions(ctx context.Context, id string, options *Options) error
+	TorrentOptions(ctx context.Context, ids ...string) (map[string]*Options, error)
 	SessionState(ctx context.Context) ([]string, error)
+	PauseSession(ctx context.Context) error
+	ResumeSession(ctx context.Context) error
+	IsSessionPaused(ctx context.Context) (bool, error)
 	ForceReannounce(ctx context.Context, ids []string) error
 	GetAvailablePlugins(ctx context.Context) ([]string, error)
 	GetEnabledPlugins(ctx context.Context) ([]string, error)
 	EnablePlugin(ctx context.Context, name string) error
 	DisablePlugin(ctx context.Context, name string) error
+	UploadPlugin(ctx context.Context, filename string, egg io.Reader) error
+	RescanPlugins(ctx context.Context) error
+	InstallPlugin(ctx context.Context, name, filename string, egg io.Reader) error
 	TestListenPort(ctx context.Context) (bool, error)
 	GetListenPort(ctx context.Context) (uint16, error)
 	GetSessionStatus(ctx context.Context) (*SessionStatus, error)
+	QueueTop(ctx context.Context, ids ...string) error
+	QueueUp(ctx context.Context, ids ...string) error
+	QueueDown(ctx context.Context, ids ...string) error
+	QueueBottom(ctx context.Context, ids ...string) error
+	QueuePositions(ctx context.Context) (map[string]int64, error)
+	Reorder(ctx context.Context, orderedIDs []string) error
+	ForceRecheck(ctx context.Context, ids ...string) error
+	ForceRecheckAndWait(ctx context.Context, ids []string, interval time.Duration, progress RecheckProgressFunc) ([]string, error)
+	WaitRecheck(ctx context.Context, ids []string, interval time.Duration, progress RecheckProgressFunc) ([]string, error)
+	TorrentFiles(ctx context.Context, id string) ([]File, error)
+	RenameFiles(ctx context.Context, id string, renames map[int]string) error
+	RenameFolder(ctx context.Context, id, oldPath, newPath string) error
+	FilePriorities(ctx context.Context, id string) ([]FilePriority, error)
+	SetFilePriorities(ctx context.Context, id string, priorities []FilePriority) error
+	SkipByGlob(ctx context.Context, id string, patterns ...string) ([]int, error)
+	SetPriorityByGlob(ctx context.Context, id string, priority FilePriority, patterns ...string) ([]int, error)
+	CreateTorrent(ctx context.Context, req CreateTorrentRequest) (*CreatedTorrent, error)
+	AddTorrentFiles(ctx context.Context, files []TorrentFile) ([]AddTorrentResult, error)
+	AddTorrentReader(ctx context.Context, fileName string, r io.Reader, options *Options) (string, error)
+	AddTorrentPath(ctx context.Context, path string, options *Options) (string, error)
+	GetConfig(ctx context.Context) (*CoreConfig, error)
+	GetConfigValue(ctx context.Context, key string) (interface{}, error)
+	GetConfigValues(ctx context.Context, keys ...string) (map[string]interface{}, error)
+	SetConfig(ctx context.Context, cfg *CoreConfig) error
+	Plan(ctx context.Context, desired *DesiredState) (*StatePlan, error)
+	ApplyPlan(ctx context.Context, plan *StatePlan) error
+	Apply(ctx context.Context, desired *DesiredState) (*StatePlan, error)
+	GetProxy(ctx context.Context) (*ProxyConfig, error)
+	SetProxy(ctx context.Context, proxy ProxyConfig) error
+	SetProxyAndVerify(ctx context.Context, proxy ProxyConfig) error
+	GetPathSize(ctx context.Context, path string) (int64, error)
+	Glob(ctx context.Context, pattern string) ([]string, error)
+	GetCompletionPaths(ctx context.Context, text string, showHidden bool) ([]string, error)
+	GetFilterTree(ctx context.Context, showZero bool, hide ...FilterCategory) (FilterTree, error)
+	NewStatusWatcher(filter TorrentFilter, opts ...StatusOption) *StatusWatcher
+	FindTorrents(ctx context.Context, f *Filter, opts ...StatusOption) (map[string]*TorrentStatus, error)
+	RunQuery(ctx context.Context, q *Query, opts ...StatusOption) ([]QueryResult, int, error)
+	Search(ctx context.Context, query string, opts ...StatusOption) ([]QueryResult, int, error)
 }
 
 // V2 is an interface for v2 Deluge clients.
@@ -101,10 +159,12 @@ type V2 interface {
 	CreateAccount(ctx context.Context, account Account) (bool, error)
 	RemoveAccount(ctx context.Context, username string) (bool, error)
 	UpdateAccount(ctx context.Context, account Account) (bool, error)
+	PrefetchMagnetMetadata(ctx context.Context, magnetURI string, timeout time.Duration) (*Metainfo, error)
 }
 
 // Client is a Deluge RPC client.
 type Client struct {
+	mu         sync.Mutex
 	settings   Settings
 	safeConn   io.ReadWriteCloser
 	serial     int64
@@ -297,6 +357,10 @@ func (c *Client) Close() error {
 const Deluge2ProtocolVersion = 1
 
 func (c *Client) rpc(ctx context.Context, methodName string, args rencode.List, kwargs rencode.Dictionary) (*Response, error) {
+	// a single request can be in flight on the connection
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	// generate serial
 	c.serial++
 	if c.serial == math.MaxInt64 {
@@ -488,7 +552,7 @@ func (c *Client) handleRPCResponse(d *rencode.Decoder, expectedSerial int64) (*R
 func (c *Client) Connect(ctx context.Context) error {
 	dialer := new(net.Dialer)
 
-	rawConn, err := dialer.DialContext(ctx, "tcp", fmt.Sprintf("%s:%d", c.settings.Hostname, c.settings.Port))
+	rawConn, err := dialer.DialContext(ctx, "tcp", c.address())
 	if err != nil {
 		return err
 	}
@@ -560,6 +624,18 @@ func (c *Client) rpcWithStringsResult(ctx context.Context, method string) ([]str
 	return result, nil
 }
 
+func (c *Client) rpcWithNoResult(ctx context.Context, methodName string, args rencode.List, kwargs rencode.Dictionary) error {
+	resp, err := c.rpc(ctx, methodName, args, kwargs)
+	if err != nil {
+		return err
+	}
+	if resp.IsError() {
+		return resp.RPCError
+	}
+
+	return nil
+}
+
 func (c *Client) rpcWithDictionaryResult(ctx context.Context, methodName string, args rencode.List, kwargs rencode.Dictionary) (rencode.Dictionary, error) {
 	var (
 		rd rencode.Dictionary
@@ -585,6 +661,70 @@ func (c *Client) rpcWithDictionaryResult(ctx context.Context, methodName string,
 	return rd, nil
 }
 
+func (c *Client) rpcWithBoolResult(ctx context.Context, method string, args rencode.List) (bool, error) {
+	resp, err := c.rpc(ctx, method, args, rencode.Dictionary{})
+	if err != nil {
+		return false, err
+	}
+	if resp.IsError() {
+		return false, resp.RPCError
+	}
+
+	vals := resp.returnValue.Values()
+	if len(vals) == 0 {
+		return false, ErrInvalidReturnValue
+	}
+	success, ok := vals[0].(bool)
+	if !ok {
+		return false, ErrInvalidReturnValue
+	}
+
+	return success, nil
+}
+
+// rpcWithHashResult calls a method returning the hash of an added torrent, which
+// is nil if the torrent was already added.
+func (c *Client) rpcWithHashResult(ctx context.Context, method string, args rencode.List) (string, error) {
+	resp, err := c.rpc(ctx, method, args, rencode.Dictionary{})
+	if err != nil {
+		return "", err
+	}
+	if resp.IsError() {
+		return "", resp.RPCError
+	}
+
+	vals := resp.returnValue.Values()
+	if len(vals) == 0 {
+		return "", ErrInvalidReturnValue
+	}
+	torrentHash := vals[0]
+	if torrentHash == nil {
+		return "", nil
+	}
+	b, ok := torrentHash.([]byte)
+	if !ok {
+		return "", ErrInvalidReturnValue
+	}
+	return string(b), nil
+}
+
+// scanDictionaryValue converts the value of the given key into dest; false is returned
+// when the key is missing or has a nil value.
+func scanDictionaryValue(d rencode.Dictionary, key string, dest interface{}) (bool, error) {
+	v, ok := d.Get(key)
+	if !ok || v == nil {
+		return false, nil
+	}
+
+	l := rencode.NewList(v)
+	err := l.Scan(dest)
+	if err != nil {
+		return false, fmt.Errorf("key %q: %v", key, err)
+	}
+
+	return true, nil
+}
+
 // DaemonVersion returns the running daemon version.
 func (c *Client) DaemonVersion(ctx context.Context) (string, error) {
 	resp, err := c.rpc(ctx, "daemon.info", rencode.List{}, rencode.Dictionary{})
@@ -0,0 +1,195 @@
+// go-libdeluge v0.5.6 - a native deluge RPC client library
+// Copyright (C) 2015~2023 gdm85 - https://github.com/gdm85/go-libdeluge/
+// This program is free software; you can redistribute it and/or
+// modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; either version 2
+// of the License, or (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// You should have received a copy of the GNU General Public License
+// along with this program; if not, write to the Free Software
+// Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+
+package deluge
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/gdm85/go-rencode"
+)
+
+// FilePriority is the download priority of a file inside a torrent.
+type FilePriority int64
+
+// The file priorities as defined in
+// https://github.com/deluge-torrent/deluge/blob/deluge-2.0.3/deluge/common.py#L107-L112
+// Deluge v1.3 has no low priority and uses different values, which are converted automatically.
+const (
+	FilePrioritySkip   FilePriority = 0
+	FilePriorityLow    FilePriority = 1
+	FilePriorityNormal FilePriority = 4
+	FilePriorityHigh   FilePriority = 7
+)
+
+func (p FilePriority) String() string {
+	switch p {
+	case FilePrioritySkip:
+		return "Skip"
+	case FilePriorityLow:
+		return "Low"
+	case FilePriorityNormal:
+		return "Normal"
+	case FilePriorityHigh:
+		return "High"
+	}
+	return fmt.Sprintf("FilePriority(%d)", int64(p))
+}
+
+// toWire returns the value understood by the daemon.
+func (p FilePriority) toWire(v2daemon bool) int64 {
+	if v2daemon {
+		return int64(p)
+	}
+
+	// v1 values: 0 do not download, 1 normal, 2 high, 5 highest
+	switch {
+	case p <= FilePrioritySkip:
+		return 0
+	case p < FilePriorityHigh:
+		return 1
+	}
+	return 2
+}
+
+// filePriorityFromWire converts a value reported by the daemon.
+func filePriorityFromWire(v int64, v2daemon bool) FilePriority {
+	switch {
+	case v <= 0:
+		return FilePrioritySkip
+	case v2daemon && v < int64(FilePriorityNormal):
+		return FilePriorityLow
+	case v2daemon && v < int64(FilePriorityHigh)-1:
+		return FilePriorityNormal
+	case !v2daemon && v == 1:
+		return FilePriorityNormal
+	}
+	return FilePriorityHigh
+}
+
+func filePrioritiesToList(priorities []FilePriority, v2daemon bool) rencode.List {
+	var list rencode.List
+	for _, p := range priorities {
+		list.Add(p.toWire(v2daemon))
+	}
+	return list
+}
+
+// FilePriorities returns the priority of each file of the torrent with the given hash,
+// in the same order as the torrent files.
+func (c *Client) FilePriorities(ctx context.Context, id string) ([]FilePriority, error) {
+	_, priorities, err := c.filesWithPriorities(ctx, id)
+	return priorities, err
+}
+
+// SetFilePriorities sets the priority of each file of the torrent with the given hash;
+// a priority must be specified for every file.
+func (c *Client) SetFilePriorities(ctx context.Context, id string, priorities []FilePriority) error {
+	return c.SetTorrentOptions(ctx, id, &Options{
+		FilePriorities: priorities,
+	})
+}
+
+// SkipByGlob sets the Skip priority for all the files of the torrent with the given
+// hash matching any of the patterns, see SetPriorityByGlob.
+func (c *Client) SkipByGlob(ctx context.Context, id string, patterns ...string) ([]int, error) {
+	return c.SetPriorityByGlob(ctx, id, FilePrioritySkip, patterns...)
+}
+
+// SetPriorityByGlob sets the priority for all the files of the torrent with the given
+// hash matching any of the patterns and returns the indexes of the matched files.
+// Patterns use the path.Match syntax and are matched against the full file path as well as
+// against any trailing part of it, so that "*.nfo" and "Sample/*" match at any depth.
+func (c *Client) SetPriorityByGlob(ctx context.Context, id string, priority FilePriority, patterns ...string) ([]int, error) {
+	normalized := make([]string, len(patterns))
+	for i, pattern := range patterns {
+		normalized[i] = strings.ReplaceAll(pattern, "\\", "/")
+		_, err := path.Match(normalized[i], "")
+		if err != nil {
+			return nil, fmt.Errorf("pattern %q: %w", pattern, err)
+		}
+	}
+
+	files, priorities, err := c.filesWithPriorities(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) != len(priorities) {
+		return nil, ErrInvalidReturnValue
+	}
+
+	var matched []int
+	changed := false
+	for i, f := range files {
+		if !matchFileGlob(normalized, f.Path) {
+			continue
+		}
+		matched = append(matched, int(f.Index))
+		if priorities[i] != priority {
+			priorities[i] = priority
+			changed = true
+		}
+	}
+	if !changed {
+		return matched, nil
+	}
+
+	return matched, c.SetFilePriorities(ctx, id, priorities)
+}
+
+func (c *Client) filesWithPriorities(ctx context.Context, id string) ([]File, []FilePriority, error) {
+	var args rencode.List
+	args.Add(id)
+	args.Add(rencode.NewList("files", "file_priorities"))
+
+	rd, err := c.rpcWithDictionaryResult(ctx, "core.get_torrent_status", args, rencode.Dictionary{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var s struct {
+		Files          []File
+		FilePriorities []int64
+	}
+	err = rd.ToStruct(&s, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	priorities := make([]FilePriority, len(s.FilePriorities))
+	for i, v := range s.FilePriorities {
+		priorities[i] = filePriorityFromWire(v, c.v2daemon)
+	}
+
+	return s.Files, priorities, nil
+}
+
+func matchFileGlob(patterns []string, p string) bool {
+	for _, pattern := range patterns {
+		for suffix := p; ; {
+			if ok, _ := path.Match(pattern, suffix); ok {
+				return true
+			}
+			i := strings.IndexByte(suffix, '/')
+			if i < 0 {
+				break
+			}
+			suffix = suffix[i+1:]
+		}
+	}
+	return false
+}
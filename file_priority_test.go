@@ -0,0 +1,78 @@
+package deluge
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/gdm85/go-rencode"
+)
+
+func TestMatchFileGlob(t *testing.T) {
+	t.Parallel()
+
+	patterns := []string{"*.nfo", "Sample/*"}
+	for p, expected := range map[string]bool{
+		"Show.S01/Show.S01E01.mkv":   false,
+		"Show.S01/Show.S01.nfo":      true,
+		"Show.S01/Sample/sample.mkv": true,
+		"release.nfo":                true,
+		"Show.S01/Samples/x.mkv":     false,
+	} {
+		if matchFileGlob(patterns, p) != expected {
+			t.Errorf("%q: expected match to be %v", p, expected)
+		}
+	}
+}
+
+func TestSkipByGlob(t *testing.T) {
+	t.Parallel()
+
+	var set []interface{}
+	c := newFakeClientV2(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		switch method {
+		case "core.get_torrent_status":
+			var files, priorities rencode.List
+			for _, f := range testFiles {
+				var d rencode.Dictionary
+				d.Add("index", f.Index)
+				d.Add("size", f.Size)
+				d.Add("offset", f.Offset)
+				d.Add("path", f.Path)
+				files.Add(d)
+				priorities.Add(int(FilePriorityNormal))
+			}
+			var st rencode.Dictionary
+			st.Add("files", files)
+			st.Add("file_priorities", priorities)
+			return st, nil
+		case "core.set_torrent_options":
+			var (
+				id      string
+				options rencode.Dictionary
+			)
+			err := args.Scan(&id, &options)
+			if err != nil {
+				return nil, err
+			}
+			v, _ := options.Get("file_priorities")
+			l := v.(rencode.List)
+			set = l.Values()
+			return nil, nil
+		}
+		return nil, RPCError{ExceptionType: "AttributeError", ExceptionMessage: method}
+	})
+
+	matched, err := c.SkipByGlob(context.Background(), testMagnetHash, "Sample/*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(matched, []int{2}) {
+		t.Errorf("unexpected matched files %v", matched)
+	}
+
+	expected := []interface{}{int8(4), int8(4), int8(0)}
+	if !reflect.DeepEqual(set, expected) {
+		t.Errorf("expected priorities %v, got %v", expected, set)
+	}
+}
@@ -0,0 +1,180 @@
+// go-libdeluge v0.5.6 - a native deluge RPC client library
+// Copyright (C) 2015~2023 gdm85 - https://github.com/gdm85/go-libdeluge/
+// This program is free software; you can redistribute it and/or
+// modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; either version 2
+// of the License, or (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// You should have received a copy of the GNU General Public License
+// along with this program; if not, write to the Free Software
+// Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+
+package deluge
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"time"
+)
+
+// Filter selects torrents with conditions which are all required to match.
+// State, IDs, label, tracker host and owner are evaluated by the daemon, the
+// other conditions locally on the returned status.
+// Filter methods modify and return the same filter, so that calls can be chained:
+//
+//	f := NewFilter().State(StateSeeding).Label("movies").RatioAtLeast(2)
+type Filter struct {
+	daemon     TorrentFilter
+	predicates []func(*TorrentStatus) bool
+	fields     []StatusField
+	v2only     bool
+	err        error
+}
+
+// NewFilter returns a filter matching all the torrents.
+func NewFilter() *Filter {
+	return &Filter{}
+}
+
+// State matches the torrents in the given state, including the special StateActive.
+func (f *Filter) State(state TorrentState) *Filter {
+	f.daemon.State = state
+	return f
+}
+
+// IDs matches the torrents with the given hashes.
+func (f *Filter) IDs(ids ...string) *Filter {
+	f.daemon.IDs = ids
+	return f
+}
+
+// Label matches the torrents with the given label; the Label plugin must be enabled.
+func (f *Filter) Label(label string) *Filter {
+	f.daemon.Label = label
+	f.daemon.Unlabeled = false
+	return f
+}
+
+// Unlabeled matches the torrents without label; the Label plugin must be enabled.
+func (f *Filter) Unlabeled() *Filter {
+	f.daemon.Label = ""
+	f.daemon.Unlabeled = true
+	return f
+}
+
+// TrackerHost matches the torrents with the given tracker host, or "Error" for tracker errors.
+func (f *Filter) TrackerHost(host string) *Filter {
+	f.daemon.TrackerHost = host
+	return f
+}
+
+// Owner matches the torrents owned by the given account; v2 daemons only.
+func (f *Filter) Owner(owner string) *Filter {
+	f.daemon.Owner = owner
+	return f
+}
+
+// Where adds a condition evaluated locally, which needs the given fields.
+func (f *Filter) Where(predicate func(*TorrentStatus) bool, fields ...StatusField) *Filter {
+	f.predicates = append(f.predicates, predicate)
+	f.fields = append(f.fields, fields...)
+	return f
+}
+
+// RatioAtLeast matches the torrents with a share ratio greater than or equal to ratio.
+func (f *Filter) RatioAtLeast(ratio float32) *Filter {
+	return f.Where(func(ts *TorrentStatus) bool { return ts.Ratio >= ratio }, FieldRatio)
+}
+
+// RatioBelow matches the torrents with a share ratio lower than ratio.
+func (f *Filter) RatioBelow(ratio float32) *Filter {
+	return f.Where(func(ts *TorrentStatus) bool { return ts.Ratio < ratio }, FieldRatio)
+}
+
+// SeedingTimeAtLeast matches the torrents seeded for at least d.
+func (f *Filter) SeedingTimeAtLeast(d time.Duration) *Filter {
+	seconds := int64(d / time.Second)
+	return f.Where(func(ts *TorrentStatus) bool { return ts.SeedingTime >= seconds }, FieldSeedingTime)
+}
+
+// SeedingTimeBelow matches the torrents seeded for less than d.
+func (f *Filter) SeedingTimeBelow(d time.Duration) *Filter {
+	seconds := int64(d / time.Second)
+	return f.Where(func(ts *TorrentStatus) bool { return ts.SeedingTime < seconds }, FieldSeedingTime)
+}
+
+// SizeAtLeast matches the torrents with a total size of at least size bytes.
+func (f *Filter) SizeAtLeast(size int64) *Filter {
+	return f.Where(func(ts *TorrentStatus) bool { return ts.TotalSize >= size }, FieldTotalSize)
+}
+
+// SizeBelow matches the torrents with a total size lower than size bytes.
+func (f *Filter) SizeBelow(size int64) *Filter {
+	return f.Where(func(ts *TorrentStatus) bool { return ts.TotalSize < size }, FieldTotalSize)
+}
+
+// NameMatches matches the torrents whose name matches the regular expression;
+// an invalid expression is reported when the filter is used.
+func (f *Filter) NameMatches(expr string) *Filter {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		if f.err == nil {
+			f.err = err
+		}
+		return f
+	}
+	return f.Where(func(ts *TorrentStatus) bool { return re.MatchString(ts.Name) }, FieldName)
+}
+
+// CompletedBefore matches the torrents which finished downloading before t; v2 daemons only.
+func (f *Filter) CompletedBefore(t time.Time) *Filter {
+	f.v2only = true
+	unix := t.Unix()
+	return f.Where(func(ts *TorrentStatus) bool {
+		return ts.CompletedTime > 0 && ts.CompletedTime < unix
+	}, FieldCompletedTime)
+}
+
+// Match evaluates the local conditions on a status.
+func (f *Filter) Match(ts *TorrentStatus) bool {
+	for _, p := range f.predicates {
+		if !p(ts) {
+			return false
+		}
+	}
+	return true
+}
+
+// FindTorrents returns the status of the torrents matching the filter.
+// When fields are selected with WithFields, the fields needed by the local conditions
+// are requested as well.
+func (c *Client) FindTorrents(ctx context.Context, f *Filter, opts ...StatusOption) (map[string]*TorrentStatus, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if f.v2only && !c.v2daemon {
+		return nil, errors.New("filter requires a v2 daemon")
+	}
+
+	o := newStatusOptions(opts)
+	if len(o.fields) != 0 {
+		opts = append(opts, WithFields(f.fields...))
+	}
+
+	torrents, err := c.TorrentsStatusFiltered(ctx, f.daemon, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	for id, ts := range torrents {
+		if !f.Match(ts) {
+			delete(torrents, id)
+		}
+	}
+
+	return torrents, nil
+}
@@ -0,0 +1,74 @@
+package deluge
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gdm85/go-rencode"
+)
+
+func TestFindTorrents(t *testing.T) {
+	t.Parallel()
+
+	torrent := func(name string, ratio float32, seedingTime int64) rencode.Dictionary {
+		var d rencode.Dictionary
+		d.Add("name", name)
+		d.Add("ratio", ratio)
+		d.Add("seeding_time", seedingTime)
+		return d
+	}
+
+	c := newFakeClientV2(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		vals := normalizeRencode(args).([]interface{})
+		expectedFilter := map[string]interface{}{"state": "Seeding", "label": "movies"}
+		if !reflect.DeepEqual(vals[0], expectedFilter) {
+			t.Errorf("unexpected daemon filter %v", vals[0])
+		}
+		expectedKeys := []interface{}{"name", "ratio", "seeding_time"}
+		if !reflect.DeepEqual(vals[1], expectedKeys) {
+			t.Errorf("unexpected keys %v", vals[1])
+		}
+
+		var d rencode.Dictionary
+		d.Add("a", torrent("Movie.2019.1080p", 2.5, 86400*10))
+		d.Add("b", torrent("Movie.2020.720p", 2.5, 86400*10))
+		d.Add("c", torrent("Movie.2021.1080p", 0.5, 86400*10))
+		d.Add("d", torrent("Movie.2022.1080p", 3, 3600))
+		return d, nil
+	})
+
+	f := NewFilter().
+		State(StateSeeding).
+		Label("movies").
+		RatioAtLeast(2).
+		SeedingTimeAtLeast(7 * 24 * time.Hour).
+		NameMatches(`1080p$`)
+	torrents, err := c.FindTorrents(context.Background(), f, WithFields(FieldName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(torrents) != 1 || torrents["a"] == nil {
+		t.Errorf("unexpected torrents %v", torrents)
+	}
+}
+
+func TestFilterErrors(t *testing.T) {
+	t.Parallel()
+
+	c := newFakeClient(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		t.Errorf("unexpected call to %s", method)
+		return nil, nil
+	})
+
+	_, err := c.FindTorrents(context.Background(), NewFilter().NameMatches("("))
+	if err == nil {
+		t.Error("expected invalid expression error")
+	}
+
+	_, err = c.FindTorrents(context.Background(), NewFilter().CompletedBefore(time.Now()))
+	if err == nil {
+		t.Error("expected error for v2-only filter on v1 daemon")
+	}
+}
@@ -0,0 +1,159 @@
+// go-libdeluge v0.5.6 - a native deluge RPC client library
+// Copyright (C) 2015~2023 gdm85 - https://github.com/gdm85/go-libdeluge/
+// This program is free software; you can redistribute it and/or
+// modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; either version 2
+// of the License, or (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// You should have received a copy of the GNU General Public License
+// along with this program; if not, write to the Free Software
+// Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+
+package deluge
+
+import (
+	"context"
+
+	"github.com/gdm85/go-rencode"
+)
+
+// FilterCategory is a category of the filter tree, as shown in the sidebar of the official UIs.
+type FilterCategory string
+
+const (
+	FilterState       FilterCategory = "state"
+	FilterTrackerHost FilterCategory = "tracker_host"
+	// FilterLabel is only available when the Label plugin is enabled.
+	FilterLabel FilterCategory = "label"
+	// FilterOwner is v2-only.
+	FilterOwner FilterCategory = "owner"
+)
+
+// filterAll is the value of the filter tree nodes matching all torrents.
+const filterAll = "All"
+
+// FilterNode is a value of a filter tree category with the number of matching torrents.
+type FilterNode struct {
+	Category FilterCategory
+	// Value is e.g. a state, a tracker host or a label; the empty label is for
+	// torrents without label and "All" matches all the torrents.
+	Value string
+	Count int64
+}
+
+// Filter returns the daemon-side filter matching the torrents counted by the node.
+func (n FilterNode) Filter() TorrentFilter {
+	var f TorrentFilter
+	if n.Value == filterAll {
+		return f
+	}
+	switch n.Category {
+	case FilterState:
+		f.State = TorrentState(n.Value)
+	case FilterTrackerHost:
+		f.TrackerHost = n.Value
+	case FilterLabel:
+		f.Label = n.Value
+		f.Unlabeled = n.Value == ""
+	case FilterOwner:
+		f.Owner = n.Value
+	}
+	return f
+}
+
+// FilterTree maps each category to its nodes, in the order returned by the daemon.
+type FilterTree map[FilterCategory][]FilterNode
+
+// Count returns the number of torrents of a node, zero if missing.
+func (t FilterTree) Count(category FilterCategory, value string) int64 {
+	for _, n := range t[category] {
+		if n.Value == value {
+			return n.Count
+		}
+	}
+	return 0
+}
+
+// TorrentFilter is a filter applied by the daemon; empty fields match all torrents.
+type TorrentFilter struct {
+	IDs         []string
+	State       TorrentState
+	TrackerHost string
+	Label       string
+	// Unlabeled matches the torrents without label, instead of Label.
+	Unlabeled bool
+	Owner     string
+}
+
+func (f TorrentFilter) toDictionary() rencode.Dictionary {
+	var dict rencode.Dictionary
+	if len(f.IDs) != 0 {
+		dict.Add("id", sliceToRencodeList(f.IDs))
+	}
+	if f.State != StateUnspecified {
+		dict.Add("state", string(f.State))
+	}
+	if f.TrackerHost != "" {
+		dict.Add("tracker_host", f.TrackerHost)
+	}
+	if f.Unlabeled {
+		dict.Add("label", "")
+	} else if f.Label != "" {
+		dict.Add("label", f.Label)
+	}
+	if f.Owner != "" {
+		dict.Add("owner", f.Owner)
+	}
+	return dict
+}
+
+// GetFilterTree returns the number of torrents for each state, tracker host, label and owner.
+// Nodes without torrents are included when showZero is true; hidden categories are not returned.
+func (c *Client) GetFilterTree(ctx context.Context, showZero bool, hide ...FilterCategory) (FilterTree, error) {
+	var args rencode.List
+	args.Add(showZero)
+	if len(hide) == 0 {
+		args.Add(nil)
+	} else {
+		var list rencode.List
+		for _, category := range hide {
+			list.Add(string(category))
+		}
+		args.Add(list)
+	}
+
+	rd, err := c.rpcWithDictionaryResult(ctx, "core.get_filter_tree", args, rencode.Dictionary{})
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := rd.Zip()
+	if err != nil {
+		return nil, err
+	}
+
+	tree := make(FilterTree, len(d))
+	for category, rv := range d {
+		nodes, ok := rv.(rencode.List)
+		if !ok {
+			return nil, ErrInvalidReturnValue
+		}
+		for _, rn := range nodes.Values() {
+			pair, ok := rn.(rencode.List)
+			if !ok || pair.Length() != 2 {
+				return nil, ErrInvalidReturnValue
+			}
+			n := FilterNode{Category: FilterCategory(category)}
+			err = pair.Scan(&n.Value, &n.Count)
+			if err != nil {
+				return nil, err
+			}
+			tree[n.Category] = append(tree[n.Category], n)
+		}
+	}
+
+	return tree, nil
+}
@@ -0,0 +1,60 @@
+package deluge
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/gdm85/go-rencode"
+)
+
+func TestGetFilterTree(t *testing.T) {
+	t.Parallel()
+
+	var filters []map[string]interface{}
+	c := newFakeClientV2(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		vals := normalizeRencode(args).([]interface{})
+		switch method {
+		case "core.get_filter_tree":
+			if !reflect.DeepEqual(vals, []interface{}{false, []interface{}{"owner"}}) {
+				t.Errorf("unexpected arguments %v", vals)
+			}
+			var d rencode.Dictionary
+			d.Add("state", rencode.NewList(rencode.NewList("All", 3), rencode.NewList("Seeding", 2), rencode.NewList("Paused", 1)))
+			d.Add("tracker_host", rencode.NewList(rencode.NewList("All", 3), rencode.NewList("example.com", 3)))
+			d.Add("label", rencode.NewList(rencode.NewList("", 1), rencode.NewList("movies", 2)))
+			return d, nil
+		case "core.get_torrents_status":
+			filters = append(filters, vals[0].(map[string]interface{}))
+			return rencode.Dictionary{}, nil
+		}
+		return nil, RPCError{ExceptionType: "AttributeError", ExceptionMessage: method}
+	})
+
+	tree, err := c.GetFilterTree(context.Background(), false, FilterOwner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.Count(FilterState, "Seeding") != 2 || tree.Count(FilterLabel, "movies") != 2 {
+		t.Errorf("unexpected tree %v", tree)
+	}
+	if len(tree[FilterState]) != 3 || tree[FilterState][0].Value != "All" {
+		t.Errorf("unexpected state nodes %v", tree[FilterState])
+	}
+
+	for _, n := range []FilterNode{tree[FilterState][0], tree[FilterState][1], tree[FilterLabel][0]} {
+		_, err = c.TorrentsStatusFiltered(context.Background(), n.Filter())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := []map[string]interface{}{
+		{},
+		{"state": "Seeding"},
+		{"label": ""},
+	}
+	if !reflect.DeepEqual(filters, expected) {
+		t.Errorf("unexpected filters %v", filters)
+	}
+}
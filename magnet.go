@@ -0,0 +1,230 @@
+// go-libdeluge v0.5.6 - a native deluge RPC client library
+// Copyright (C) 2015~2023 gdm85 - https://github.com/gdm85/go-libdeluge/
+// This program is free software; you can redistribute it and/or
+// modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; either version 2
+// of the License, or (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// You should have received a copy of the GNU General Public License
+// along with this program; if not, write to the Free Software
+// Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+
+package deluge
+
+import (
+	"context"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	btihPrefix = "urn:btih:"
+	btmhPrefix = "urn:btmh:"
+	// sha256Multihash is the multihash prefix of a 32 bytes SHA-256 digest.
+	sha256Multihash = "1220"
+)
+
+var (
+	// ErrInvalidMagnet is returned when a magnet URI cannot be parsed.
+	ErrInvalidMagnet = errors.New("invalid magnet URI")
+)
+
+// Magnet is a parsed magnet URI.
+type Magnet struct {
+	// InfoHash is the v1 info hash as 40 lowercase hex characters.
+	InfoHash string
+	// InfoHashV2 is the v2 (SHA-256) info hash as 64 lowercase hex characters.
+	InfoHashV2  string
+	DisplayName string
+	Trackers    []string
+	WebSeeds    []string
+	// ExactLength is the total size in bytes, zero if unknown.
+	ExactLength int64
+	// SelectOnly lists the indexes of the files to download, all when empty.
+	SelectOnly []int
+}
+
+// ParseMagnet parses a magnet URI with a v1 (hex or base32) and/or v2 info hash.
+func ParseMagnet(uri string) (*Magnet, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMagnet, err)
+	}
+	if u.Scheme != "magnet" {
+		return nil, fmt.Errorf("%w: scheme %q", ErrInvalidMagnet, u.Scheme)
+	}
+	q, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMagnet, err)
+	}
+
+	var m Magnet
+	for _, xt := range q["xt"] {
+		switch {
+		case strings.HasPrefix(strings.ToLower(xt), btihPrefix):
+			m.InfoHash, err = parseBtih(xt[len(btihPrefix):])
+		case strings.HasPrefix(strings.ToLower(xt), btmhPrefix):
+			m.InfoHashV2, err = parseBtmh(xt[len(btmhPrefix):])
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if m.InfoHash == "" && m.InfoHashV2 == "" {
+		return nil, fmt.Errorf("%w: missing info hash", ErrInvalidMagnet)
+	}
+
+	m.DisplayName = q.Get("dn")
+	m.Trackers = q["tr"]
+	m.WebSeeds = q["ws"]
+	if xl := q.Get("xl"); xl != "" {
+		m.ExactLength, err = strconv.ParseInt(xl, 10, 64)
+		if err != nil || m.ExactLength < 0 {
+			return nil, fmt.Errorf("%w: exact length %q", ErrInvalidMagnet, xl)
+		}
+	}
+	if so := q.Get("so"); so != "" {
+		m.SelectOnly, err = parseSelectOnly(so)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &m, nil
+}
+
+func parseBtih(s string) (string, error) {
+	switch len(s) {
+	case 40:
+		_, err := hex.DecodeString(s)
+		if err == nil {
+			return strings.ToLower(s), nil
+		}
+	case 32:
+		b, err := base32.StdEncoding.DecodeString(strings.ToUpper(s))
+		if err == nil {
+			return hex.EncodeToString(b), nil
+		}
+	}
+	return "", fmt.Errorf("%w: btih %q", ErrInvalidMagnet, s)
+}
+
+func parseBtmh(s string) (string, error) {
+	s = strings.ToLower(s)
+	if len(s) != len(sha256Multihash)+64 || !strings.HasPrefix(s, sha256Multihash) {
+		return "", fmt.Errorf("%w: btmh %q", ErrInvalidMagnet, s)
+	}
+	_, err := hex.DecodeString(s)
+	if err != nil {
+		return "", fmt.Errorf("%w: btmh %q", ErrInvalidMagnet, s)
+	}
+	return s[len(sha256Multihash):], nil
+}
+
+// maxSelectOnlyIndexes bounds the number of file indexes of an untrusted select-only
+// parameter, as ranges are expanded.
+const maxSelectOnlyIndexes = 1 << 16
+
+// parseSelectOnly parses a list of file indexes and ranges like "0,2,4-6".
+func parseSelectOnly(s string) ([]int, error) {
+	var result []int
+	for _, part := range strings.Split(s, ",") {
+		from, to, isRange := strings.Cut(part, "-")
+		first, err := strconv.Atoi(from)
+		last := first
+		if err == nil && isRange {
+			last, err = strconv.Atoi(to)
+		}
+		if err != nil || first < 0 || last < first {
+			return nil, fmt.Errorf("%w: select-only %q", ErrInvalidMagnet, s)
+		}
+		if last-first >= maxSelectOnlyIndexes-len(result) {
+			return nil, fmt.Errorf("%w: select-only lists more than %d files", ErrInvalidMagnet, maxSelectOnlyIndexes)
+		}
+		for i := first; i <= last; i++ {
+			result = append(result, i)
+		}
+	}
+	sort.Ints(result)
+	return result, nil
+}
+
+// Hash returns the torrent ID as used by TorrentStatus.Hash: the v1 info hash
+// or, for v2-only torrents, the truncated v2 info hash.
+func (m Magnet) Hash() string {
+	if m.InfoHash != "" {
+		return m.InfoHash
+	}
+	return m.InfoHashV2[:40]
+}
+
+// String returns the magnet URI.
+func (m Magnet) String() string {
+	var params []string
+	if m.InfoHash != "" {
+		params = append(params, "xt="+btihPrefix+m.InfoHash)
+	}
+	if m.InfoHashV2 != "" {
+		params = append(params, "xt="+btmhPrefix+sha256Multihash+m.InfoHashV2)
+	}
+	if m.DisplayName != "" {
+		params = append(params, "dn="+url.QueryEscape(m.DisplayName))
+	}
+	if m.ExactLength > 0 {
+		params = append(params, "xl="+strconv.FormatInt(m.ExactLength, 10))
+	}
+	for _, tr := range m.Trackers {
+		params = append(params, "tr="+url.QueryEscape(tr))
+	}
+	for _, ws := range m.WebSeeds {
+		params = append(params, "ws="+url.QueryEscape(ws))
+	}
+	if len(m.SelectOnly) != 0 {
+		params = append(params, "so="+formatSelectOnly(m.SelectOnly))
+	}
+
+	return "magnet:?" + strings.Join(params, "&")
+}
+
+// formatSelectOnly compacts sorted file indexes into ranges.
+func formatSelectOnly(indexes []int) string {
+	sorted := make([]int, len(indexes))
+	copy(sorted, indexes)
+	sort.Ints(sorted)
+
+	var parts []string
+	for i := 0; i < len(sorted); {
+		j := i
+		for j+1 < len(sorted) && sorted[j+1] <= sorted[j]+1 {
+			j++
+		}
+		if sorted[j] == sorted[i] {
+			parts = append(parts, strconv.Itoa(sorted[i]))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", sorted[i], sorted[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
+
+// AddMagnet adds a torrent via a parsed magnet URI and returns the torrent hash,
+// also when the torrent was already in the session.
+func (c *Client) AddMagnet(ctx context.Context, magnet *Magnet, options *Options) (string, error) {
+	// v1 daemons return an empty hash when the torrent was already added, v2 daemons an error
+	_, err := c.AddTorrentMagnet(ctx, magnet.String(), options)
+	if err != nil && !isAlreadyInSession(err) {
+		return "", err
+	}
+
+	return magnet.Hash(), nil
+}
@@ -0,0 +1,100 @@
+package deluge
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/gdm85/go-rencode"
+)
+
+func TestParseMagnet(t *testing.T) {
+	t.Parallel()
+
+	m, err := ParseMagnet(testMagnetURI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Hash() != testMagnetHash {
+		t.Errorf("unexpected hash %q", m.Hash())
+	}
+	if !reflect.DeepEqual(m.Trackers, []string{"http://torrent.ubuntu.com:6969/announce"}) {
+		t.Errorf("unexpected trackers %v", m.Trackers)
+	}
+	if m.String() != testMagnetURI {
+		t.Errorf("expected %q, got %q", testMagnetURI, m.String())
+	}
+
+	// base32 form of the same hash, with the other supported parameters
+	const uri = "magnet:?xt=urn:btih:YGJZZJATXGX4YNHKBTZ4CKCXJ2J763FQ&dn=Ubuntu+Desktop&xl=1024&ws=http%3A%2F%2Fmirror%2Fubuntu.iso&so=0,2,3,4"
+	m, err = ParseMagnet(uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.InfoHash != testMagnetHash || m.DisplayName != "Ubuntu Desktop" || m.ExactLength != 1024 {
+		t.Errorf("unexpected magnet %+v", m)
+	}
+	if !reflect.DeepEqual(m.SelectOnly, []int{0, 2, 3, 4}) {
+		t.Errorf("unexpected select-only %v", m.SelectOnly)
+	}
+	const expected = "magnet:?xt=urn:btih:" + testMagnetHash + "&dn=Ubuntu+Desktop&xl=1024&ws=http%3A%2F%2Fmirror%2Fubuntu.iso&so=0,2-4"
+	if m.String() != expected {
+		t.Errorf("expected %q, got %q", expected, m.String())
+	}
+}
+
+func TestParseMagnetV2(t *testing.T) {
+	t.Parallel()
+
+	const v2 = "caf1e1c30e81cb361b9ee167c4aa64228a7fa4fa9f6105232b28ad099f3a302e"
+	m, err := ParseMagnet("magnet:?xt=urn:btmh:1220" + v2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.InfoHashV2 != v2 || m.Hash() != v2[:40] {
+		t.Errorf("unexpected magnet %+v", m)
+	}
+}
+
+func TestParseMagnetInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, uri := range []string{
+		"http://example.org",
+		"magnet:?dn=nohash",
+		"magnet:?xt=urn:btih:1234",
+		"magnet:?xt=urn:btmh:1111" + testMagnetHash,
+		"magnet:?xt=urn:btih:" + testMagnetHash + "&so=3-1",
+		"magnet:?xt=urn:btih:" + testMagnetHash + "&so=0-2147483647",
+		"magnet:?xt=urn:btih:" + testMagnetHash + "&so=0-40000,50000-90000",
+	} {
+		_, err := ParseMagnet(uri)
+		if !errors.Is(err, ErrInvalidMagnet) {
+			t.Errorf("%q: expected ErrInvalidMagnet, got %v", uri, err)
+		}
+	}
+}
+
+func TestAddMagnetAlreadyInSession(t *testing.T) {
+	t.Parallel()
+
+	c := newFakeClientV2(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		if method != "core.add_torrent_magnet" {
+			return nil, RPCError{ExceptionType: "AttributeError", ExceptionMessage: method}
+		}
+		return nil, RPCError{ExceptionType: "AddTorrentError", ExceptionMessage: "Torrent already in session (" + testMagnetHash + ")."}
+	})
+
+	m, err := ParseMagnet(testMagnetURI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := c.AddMagnet(context.Background(), m, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != testMagnetHash {
+		t.Errorf("expected hash %q, got %q", testMagnetHash, hash)
+	}
+}
@@ -0,0 +1,322 @@
+// go-libdeluge v0.5.6 - a native deluge RPC client library
+// Copyright (C) 2015~2023 gdm85 - https://github.com/gdm85/go-libdeluge/
+// This program is free software; you can redistribute it and/or
+// modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; either version 2
+// of the License, or (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// You should have received a copy of the GNU General Public License
+// along with this program; if not, write to the Free Software
+// Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+
+package deluge
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gdm85/go-rencode"
+)
+
+// maxTorrentFileSize is the maximum size of a .torrent file read by AddTorrentReader.
+const maxTorrentFileSize = 64 * 1024 * 1024
+
+var (
+	// ErrMetadataTimeout is returned when the daemon could not retrieve the metadata of a magnet in time.
+	ErrMetadataTimeout = errors.New("timed out retrieving magnet metadata")
+)
+
+// InvalidTorrentError is returned when the content of a .torrent file is not valid metainfo.
+type InvalidTorrentError struct {
+	Name string
+	Err  error
+}
+
+func (e InvalidTorrentError) Error() string {
+	return fmt.Sprintf("invalid torrent %q: %v", e.Name, e.Err)
+}
+
+func (e InvalidTorrentError) Unwrap() error {
+	return e.Err
+}
+
+// Metainfo is a decoded view of the metadata of a torrent.
+type Metainfo struct {
+	// Hash is the info hash used by the session as torrent id: the v1 info hash, or
+	// the truncated v2 info hash for v2-only torrents.
+	Hash      string
+	Name      string
+	TotalSize int64
+	Private   bool
+	// Files are in the same order as the torrent files in the session, so that
+	// they can be used to build the FilePriorities option; this includes the
+	// padding files of hybrid torrents.
+	Files []MetainfoFile
+}
+
+// MetainfoFile is a file described by the metadata of a torrent.
+type MetainfoFile struct {
+	// Path includes the torrent name as top folder for multi-file torrents.
+	Path string
+	Size int64
+	// Padding is true for the padding files aligning the other files to pieces,
+	// which are not included in the TotalSize.
+	Padding bool
+}
+
+// ParseMetainfo decodes the content of a .torrent file and computes its info hash;
+// an InvalidTorrentError is returned if the content is not valid metainfo.
+func ParseMetainfo(name string, data []byte) (*Metainfo, error) {
+	v, d, err := decodeBencode(data)
+	if err != nil {
+		return nil, InvalidTorrentError{name, err}
+	}
+	top, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, InvalidTorrentError{name, errors.New("not a dictionary")}
+	}
+	info, ok := top["info"].(map[string]interface{})
+	if !ok {
+		return nil, InvalidTorrentError{name, errors.New("missing info dictionary")}
+	}
+
+	m, err := parseInfoDict(info)
+	if err != nil {
+		return nil, InvalidTorrentError{name, err}
+	}
+	if _, ok := info["pieces"]; !ok && info["file tree"] != nil {
+		// v2-only torrent
+		m.Hash, err = d.infoHashV2Truncated()
+	} else {
+		m.Hash, err = d.infoHashV1()
+	}
+	if err != nil {
+		return nil, InvalidTorrentError{name, err}
+	}
+
+	return m, nil
+}
+
+// AddTorrentReader reads a .torrent file, validates it locally and adds it to the session.
+// The info hash is returned even when the torrent was already in the session.
+func (c *Client) AddTorrentReader(ctx context.Context, fileName string, r io.Reader, options *Options) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxTorrentFileSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > maxTorrentFileSize {
+		return "", InvalidTorrentError{fileName, errors.New("file too large")}
+	}
+
+	m, err := ParseMetainfo(fileName, data)
+	if err != nil {
+		return "", err
+	}
+
+	// v1 daemons return an empty hash when the torrent was already added, v2 daemons an error
+	_, err = c.AddTorrentFile(ctx, fileName, base64.StdEncoding.EncodeToString(data), options)
+	if err != nil && !isAlreadyInSession(err) {
+		return "", err
+	}
+
+	return m.Hash, nil
+}
+
+// AddTorrentPath adds the .torrent file at the given local path, see AddTorrentReader.
+func (c *Client) AddTorrentPath(ctx context.Context, path string, options *Options) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	return c.AddTorrentReader(ctx, filepath.Base(path), f, options)
+}
+
+// parseInfoDict builds a Metainfo from a decoded info dictionary, supporting
+// single-file, multi-file and v2-only torrents.
+func parseInfoDict(info map[string]interface{}) (*Metainfo, error) {
+	var m Metainfo
+
+	name, ok := info["name"].([]byte)
+	if !ok {
+		return nil, errors.New("missing name")
+	}
+	m.Name = string(name)
+	if private, ok := info["private"].(int64); ok {
+		m.Private = private == 1
+	}
+
+	if length, ok := info["length"].(int64); ok {
+		m.Files = []MetainfoFile{{Path: m.Name, Size: length}}
+	} else if files, ok := info["files"].([]interface{}); ok {
+		for i, f := range files {
+			file, ok := f.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("file %d: not a dictionary", i)
+			}
+			length, ok := file["length"].(int64)
+			if !ok {
+				return nil, fmt.Errorf("file %d: missing length", i)
+			}
+			parts, ok := file["path"].([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("file %d: missing path", i)
+			}
+
+			components := []string{m.Name}
+			for _, p := range parts {
+				b, ok := p.([]byte)
+				if !ok {
+					return nil, fmt.Errorf("file %d: invalid path", i)
+				}
+				components = append(components, string(b))
+			}
+			attr, _ := file["attr"].([]byte)
+			m.Files = append(m.Files, MetainfoFile{
+				Path:    strings.Join(components, "/"),
+				Size:    length,
+				Padding: strings.Contains(string(attr), "p"),
+			})
+		}
+	} else if tree, ok := info["file tree"].(map[string]interface{}); ok {
+		if leaf, ok := singleFileTree(tree); ok {
+			// single-file torrents have the file at the root, without top folder
+			length, _ := leaf["length"].(int64)
+			m.Files = []MetainfoFile{{Path: m.Name, Size: length}}
+		} else {
+			err := walkFileTree(tree, m.Name, &m.Files)
+			if err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		return nil, errors.New("missing files")
+	}
+
+	for _, f := range m.Files {
+		if !f.Padding {
+			m.TotalSize += f.Size
+		}
+	}
+
+	return &m, nil
+}
+
+// singleFileTree returns the file of a v2 file tree with a single file at the root.
+func singleFileTree(tree map[string]interface{}) (map[string]interface{}, bool) {
+	if len(tree) != 1 {
+		return nil, false
+	}
+	for _, node := range tree {
+		if n, ok := node.(map[string]interface{}); ok {
+			leaf, ok := n[""].(map[string]interface{})
+			return leaf, ok
+		}
+	}
+	return nil, false
+}
+
+// walkFileTree flattens a v2 file tree, whose leaves are dictionaries with an
+// empty key; entries are sorted by name as required by the specification.
+func walkFileTree(tree map[string]interface{}, prefix string, files *[]MetainfoFile) error {
+	names := make([]string, 0, len(tree))
+	for name := range tree {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		node, ok := tree[name].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("file tree %q: not a dictionary", name)
+		}
+		p := prefix + "/" + name
+		if leaf, ok := node[""].(map[string]interface{}); ok {
+			length, _ := leaf["length"].(int64)
+			*files = append(*files, MetainfoFile{Path: p, Size: length})
+			continue
+		}
+		err := walkFileTree(node, p, files)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PrefetchMagnetMetadata asks the daemon to retrieve the metadata of a magnet without
+// adding it to the session; timeout is rounded to seconds and the daemon default
+// (30 seconds) is used when zero.
+// ErrMetadataTimeout is returned when the metadata could not be retrieved in time.
+func (c *ClientV2) PrefetchMagnetMetadata(ctx context.Context, magnetURI string, timeout time.Duration) (*Metainfo, error) {
+	var args rencode.List
+	args.Add(magnetURI)
+	var kwargs rencode.Dictionary
+	if timeout > 0 {
+		kwargs.Add("timeout", int(timeout.Round(time.Second)/time.Second))
+	}
+
+	resp, err := c.rpc(ctx, "core.prefetch_magnet_metadata", args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+	if resp.IsError() {
+		return nil, resp.RPCError
+	}
+
+	var result rencode.List
+	err = resp.returnValue.Scan(&result)
+	if err != nil {
+		return nil, err
+	}
+	vals := result.Values()
+	if len(vals) != 2 {
+		return nil, ErrInvalidReturnValue
+	}
+	hash, ok := vals[0].([]byte)
+	if !ok {
+		return nil, ErrInvalidReturnValue
+	}
+	encoded, _ := vals[1].([]byte)
+	if len(encoded) == 0 {
+		return nil, ErrMetadataTimeout
+	}
+
+	metadata, err := base64.StdEncoding.DecodeString(string(encoded))
+	if err != nil {
+		return nil, err
+	}
+	v, _, err := decodeBencode(metadata)
+	if err != nil {
+		return nil, err
+	}
+	info, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, ErrInvalidReturnValue
+	}
+	// v2.0 returns a whole torrent while v2.1+ only the info dictionary
+	if inner, ok := info["info"].(map[string]interface{}); ok {
+		info = inner
+	}
+
+	m, err := parseInfoDict(info)
+	if err != nil {
+		return nil, err
+	}
+	m.Hash = string(hash)
+
+	return m, nil
+}
@@ -0,0 +1,181 @@
+package deluge
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdm85/go-rencode"
+)
+
+func TestPrefetchMagnetMetadata(t *testing.T) {
+	t.Parallel()
+
+	const info = "d5:filesld6:lengthi100e4:pathl5:a.mkveed4:attr1:p6:lengthi12e4:pathl4:.pad2:12eed6:lengthi20e4:pathl6:Sample5:b.mkveee4:name4:Show12:piece lengthi16384e6:pieces20:aaaaaaaaaaaaaaaaaaaa7:privatei1ee"
+
+	for _, metadata := range []string{info, "d4:info" + info + "e"} {
+		c := newFakeClientV2(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+			if method != "core.prefetch_magnet_metadata" {
+				return nil, RPCError{ExceptionType: "AttributeError", ExceptionMessage: method}
+			}
+			if v, ok := kwargs.Get("timeout"); !ok || v.(int8) != 5 {
+				t.Errorf("unexpected timeout %v", v)
+			}
+			return rencode.NewList(testMagnetHash, base64.StdEncoding.EncodeToString([]byte(metadata))), nil
+		})
+
+		m, err := c.PrefetchMagnetMetadata(context.Background(), testMagnetURI, 5*time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Hash != testMagnetHash || m.Name != "Show" || !m.Private || m.TotalSize != 120 {
+			t.Errorf("unexpected metainfo %+v", m)
+		}
+		if len(m.Files) != 3 || !m.Files[1].Padding || m.Files[0].Padding || m.Files[2].Path != "Show/Sample/b.mkv" {
+			t.Errorf("unexpected files %+v", m.Files)
+		}
+	}
+}
+
+func TestPrefetchMagnetMetadataTimeout(t *testing.T) {
+	t.Parallel()
+
+	c := newFakeClientV2(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		return rencode.NewList(testMagnetHash, ""), nil
+	})
+
+	_, err := c.PrefetchMagnetMetadata(context.Background(), testMagnetURI, 0)
+	if err != ErrMetadataTimeout {
+		t.Fatalf("expected ErrMetadataTimeout, got %v", err)
+	}
+}
+
+func TestAddTorrentReader(t *testing.T) {
+	t.Parallel()
+
+	c := newFakeClientV2(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		if method != "core.add_torrent_file" {
+			return nil, RPCError{ExceptionType: "AttributeError", ExceptionMessage: method}
+		}
+		// the torrent was already added
+		return nil, nil
+	})
+
+	data := testTorrent("a")
+	m, err := ParseMetainfo("a.torrent", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash, err := c.AddTorrentReader(context.Background(), "a.torrent", bytes.NewReader(data), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != m.Hash {
+		t.Errorf("expected hash %q, got %q", m.Hash, hash)
+	}
+
+	p := filepath.Join(t.TempDir(), "a.torrent")
+	err = os.WriteFile(p, data, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err = c.AddTorrentPath(context.Background(), p, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != m.Hash {
+		t.Errorf("expected hash %q, got %q", m.Hash, hash)
+	}
+
+	_, err = c.AddTorrentReader(context.Background(), "page.html", strings.NewReader("<html></html>"), nil)
+	var invalid InvalidTorrentError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("expected InvalidTorrentError, got %v", err)
+	}
+}
+
+func TestParseInfoDictFileTree(t *testing.T) {
+	t.Parallel()
+
+	leaf := func(length int64) map[string]interface{} {
+		return map[string]interface{}{"": map[string]interface{}{"length": length}}
+	}
+
+	m, err := parseInfoDict(map[string]interface{}{
+		"name":      []byte("a.mkv"),
+		"file tree": map[string]interface{}{"a.mkv": leaf(5)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Files) != 1 || m.Files[0].Path != "a.mkv" || m.TotalSize != 5 {
+		t.Errorf("unexpected single-file metainfo %+v", m)
+	}
+
+	m, err = parseInfoDict(map[string]interface{}{
+		"name": []byte("Show"),
+		"file tree": map[string]interface{}{
+			"b.mkv":  leaf(2),
+			"Sample": map[string]interface{}{"a.mkv": leaf(1)},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Files) != 2 || m.Files[0].Path != "Show/Sample/a.mkv" || m.Files[1].Path != "Show/b.mkv" {
+		t.Errorf("unexpected multi-file metainfo %+v", m.Files)
+	}
+}
+
+func TestParseMetainfoV2Only(t *testing.T) {
+	t.Parallel()
+
+	info := "d9:file treed5:a.mkvd0:d6:lengthi5e11:pieces root32:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaeee12:meta versioni2e4:name5:a.mkv12:piece lengthi16384ee"
+	data := []byte("d4:info" + info + "e")
+
+	m, err := ParseMetainfo("a.torrent", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte(info))
+	if expected := hex.EncodeToString(sum[:20]); m.Hash != expected {
+		t.Errorf("expected hash %s, got %s", expected, m.Hash)
+	}
+	if m.Name != "a.mkv" || m.TotalSize != 5 {
+		t.Errorf("unexpected metainfo %+v", m)
+	}
+}
+
+func TestAddTorrentReaderAlreadyInSession(t *testing.T) {
+	t.Parallel()
+
+	data := testTorrent("a")
+	m, err := ParseMetainfo("a.torrent", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := newFakeClientV2(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		if method != "core.add_torrent_file" {
+			return nil, RPCError{ExceptionType: "AttributeError", ExceptionMessage: method}
+		}
+		return nil, RPCError{ExceptionType: "AddTorrentError", ExceptionMessage: "Torrent already in session (" + m.Hash + ")."}
+	})
+
+	hash, err := c.AddTorrentReader(context.Background(), "a.torrent", bytes.NewReader(data), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != m.Hash {
+		t.Errorf("expected hash %q, got %q", m.Hash, hash)
+	}
+}
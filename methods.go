@@ -16,7 +16,9 @@ package deluge
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/gdm85/go-rencode"
 )
@@ -63,78 +65,52 @@ func (c *Client) GetLibtorrentVersion(ctx context.Context) (string, error) {
 }
 
 // AddTorrentMagnet adds a torrent via magnet URI and returns the torrent hash.
+// See AddMagnet to add a parsed Magnet.
 func (c *Client) AddTorrentMagnet(ctx context.Context, magnetURI string, options *Options) (string, error) {
-	var args rencode.List
-	args.Add(magnetURI, options.toDictionary(c.v2daemon))
-
-	resp, err := c.rpc(ctx, "core.add_torrent_magnet", args, rencode.Dictionary{})
+	dict, err := options.toDictionary(c.v2daemon)
 	if err != nil {
 		return "", err
 	}
-	if resp.IsError() {
-		return "", resp.RPCError
-	}
 
-	// returned hash will be nil if torrent was already added
-	vals := resp.returnValue.Values()
-	if len(vals) == 0 {
-		return "", ErrInvalidReturnValue
-	}
-	torrentHash := vals[0]
-	if torrentHash == nil {
-		return "", nil
-	}
-	return string(torrentHash.([]uint8)), nil
+	var args rencode.List
+	args.Add(magnetURI, dict)
+
+	return c.rpcWithHashResult(ctx, "core.add_torrent_magnet", args)
 }
 
 // AddTorrentURL adds a torrent via a URL and returns the torrent hash.
 func (c *Client) AddTorrentURL(ctx context.Context, url string, options *Options) (string, error) {
-	var args rencode.List
-	args.Add(url, options.toDictionary(c.v2daemon))
-
-	resp, err := c.rpc(ctx, "core.add_torrent_url", args, rencode.Dictionary{})
+	dict, err := options.toDictionary(c.v2daemon)
 	if err != nil {
 		return "", err
 	}
-	if resp.IsError() {
-		return "", resp.RPCError
-	}
 
-	// returned hash will be nil if torrent was already added
-	vals := resp.returnValue.Values()
-	if len(vals) == 0 {
-		return "", ErrInvalidReturnValue
-	}
-	torrentHash := vals[0]
-	if torrentHash == nil {
-		return "", nil
-	}
-	return string(torrentHash.([]uint8)), nil
+	var args rencode.List
+	args.Add(url, dict)
+
+	return c.rpcWithHashResult(ctx, "core.add_torrent_url", args)
 }
 
 // AddTorrentFile adds a torrent via a base64 encoded file and returns the torrent hash.
 func (c *Client) AddTorrentFile(ctx context.Context, fileName, fileContentBase64 string, options *Options) (string, error) {
-	var args rencode.List
-	args.Add(fileName, fileContentBase64, options.toDictionary(c.v2daemon))
-
-	resp, err := c.rpc(ctx, "core.add_torrent_file", args, rencode.Dictionary{})
+	dict, err := options.toDictionary(c.v2daemon)
 	if err != nil {
 		return "", err
 	}
-	if resp.IsError() {
-		return "", resp.RPCError
-	}
 
-	// returned hash will be nil if torrent was already added
-	vals := resp.returnValue.Values()
-	if len(vals) == 0 {
-		return "", ErrInvalidReturnValue
-	}
-	torrentHash := vals[0]
-	if torrentHash == nil {
-		return "", nil
-	}
-	return string(torrentHash.([]uint8)), nil
+	var args rencode.List
+	args.Add(fileName, fileContentBase64, dict)
+
+	return c.rpcWithHashResult(ctx, "core.add_torrent_file", args)
+}
+
+// isAlreadyInSession reports whether err is the AddTorrentError raised by v2 daemons
+// when adding a torrent which is already in the session; v1 daemons return no hash instead.
+func isAlreadyInSession(err error) bool {
+	var rpcErr RPCError
+	return errors.As(err, &rpcErr) &&
+		rpcErr.ExceptionType == "AddTorrentError" &&
+		strings.Contains(rpcErr.ExceptionMessage, "already in session")
 }
 
 // TorrentError is a tuple of a torrent id and an error message, returned by
@@ -287,15 +263,20 @@ func (c *Client) MoveStorage(ctx context.Context, torrentIDs []string, dest stri
 	return err
 }
 
-// SessionState returns the current session state.
+// SessionState returns the IDs of all torrents in the current session.
 func (c *Client) SessionState(ctx context.Context) ([]string, error) {
 	return c.rpcWithStringsResult(ctx, "core.get_session_state")
 }
 
 // SetTorrentOptions updates options for the torrent with the given hash.
 func (c *Client) SetTorrentOptions(ctx context.Context, id string, options *Options) error {
+	dict, err := options.toDictionary(c.v2daemon)
+	if err != nil {
+		return err
+	}
+
 	var args rencode.List
-	args.Add(id, options.toDictionary(c.v2daemon))
+	args.Add(id, dict)
 
 	resp, err := c.rpc(ctx, "core.set_torrent_options", args, rencode.Dictionary{})
 	if err != nil {
@@ -310,31 +291,18 @@ func (c *Client) SetTorrentOptions(ctx context.Context, id string, options *Opti
 
 // SetTorrentTracker sets the primary tracker for the torrent with the
 // given hash to be `trackerURL`.
+// All the other trackers are removed; use AddTrackers to keep them.
 func (c *Client) SetTorrentTracker(ctx context.Context, id, trackerURL string) error {
-	var tracker rencode.Dictionary
-	tracker.Add("url", trackerURL)
-	tracker.Add("tier", 0)
-
-	var trackers rencode.List
-	trackers.Add(tracker)
-
-	var args rencode.List
-	args.Add(id, trackers)
-
-	resp, err := c.rpc(ctx, "core.set_torrent_trackers", args, rencode.Dictionary{})
-	if err != nil {
-		return err
-	}
-	if resp.IsError() {
-		return resp.RPCError
-	}
-
-	return nil
+	return c.SetTrackers(ctx, id, []Tracker{{URL: trackerURL, Tier: 0}})
 }
 
 // KnownAccounts returns all known accounts, including password and
 // permission levels.
 func (c *ClientV2) KnownAccounts(ctx context.Context) ([]Account, error) {
+	return c.knownAccounts(ctx)
+}
+
+func (c *Client) knownAccounts(ctx context.Context) ([]Account, error) {
 	resp, err := c.rpc(ctx, "core.get_known_accounts", rencode.List{}, rencode.Dictionary{})
 	if err != nil {
 		return nil, err
@@ -373,41 +341,13 @@ func (c *ClientV2) KnownAccounts(ctx context.Context) ([]Account, error) {
 // password and permission level. The authenticated user must have an
 // authLevel of ADMIN to succeed.
 func (c *ClientV2) CreateAccount(ctx context.Context, account Account) (bool, error) {
-	resp, err := c.rpc(ctx, "core.create_account", account.toList(), rencode.Dictionary{})
-	if err != nil {
-		return false, err
-	}
-	if resp.IsError() {
-		return false, resp.RPCError
-	}
-
-	vals := resp.returnValue.Values()
-	if len(vals) == 0 {
-		return false, ErrInvalidReturnValue
-	}
-	success := vals[0]
-
-	return success.(bool), nil
+	return c.rpcWithBoolResult(ctx, "core.create_account", account.toList())
 }
 
 // UpdateAccount sets a new password and permission level for a account.
 // The authenticated user must have an authLevel of ADMIN to succeed.
 func (c *ClientV2) UpdateAccount(ctx context.Context, account Account) (bool, error) {
-	resp, err := c.rpc(ctx, "core.update_account", account.toList(), rencode.Dictionary{})
-	if err != nil {
-		return false, err
-	}
-	if resp.IsError() {
-		return false, resp.RPCError
-	}
-
-	vals := resp.returnValue.Values()
-	if len(vals) == 0 {
-		return false, ErrInvalidReturnValue
-	}
-	success := vals[0]
-
-	return success.(bool), nil
+	return c.rpcWithBoolResult(ctx, "core.update_account", account.toList())
 }
 
 // RemoveAccount will delete an existing username.
@@ -416,21 +356,7 @@ func (c *ClientV2) RemoveAccount(ctx context.Context, username string) (bool, er
 	var args rencode.List
 	args.Add(username)
 
-	resp, err := c.rpc(ctx, "core.remove_account", args, rencode.Dictionary{})
-	if err != nil {
-		return false, err
-	}
-	if resp.IsError() {
-		return false, resp.RPCError
-	}
-
-	vals := resp.returnValue.Values()
-	if len(vals) == 0 {
-		return false, ErrInvalidReturnValue
-	}
-	success := vals[0]
-
-	return success.(bool), nil
+	return c.rpcWithBoolResult(ctx, "core.remove_account", args)
 }
 
 // ForceReannounce will reannounce torrent status to associated tracker(s).
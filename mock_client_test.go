@@ -2,7 +2,12 @@ package deluge
 
 import (
 	"bytes"
+	"compress/zlib"
+	"encoding/binary"
 	"encoding/hex"
+	"io"
+
+	"github.com/gdm85/go-rencode"
 )
 
 // buffer is just here to make bytes.Buffer an io.ReadWriteCloser.
@@ -46,3 +51,119 @@ func newMockClientV2(serial int64, payload string) V2 {
 
 	return &c
 }
+
+// fakeHandler answers a single decoded RPC request; a returned RPCError is
+// sent back to the client as an error response.
+type fakeHandler func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error)
+
+// fakeDaemon is an in-memory connection which decodes every request written
+// by the client and replies through a handler, so that tests can exercise
+// methods issuing more than one RPC call.
+type fakeDaemon struct {
+	v2      bool
+	handler fakeHandler
+	req     bytes.Buffer
+	resp    bytes.Buffer
+}
+
+func (f *fakeDaemon) Write(p []byte) (int, error) {
+	return f.req.Write(p)
+}
+
+func (f *fakeDaemon) Read(p []byte) (int, error) {
+	if f.resp.Len() == 0 {
+		if f.req.Len() == 0 {
+			return 0, io.EOF
+		}
+		err := f.serve()
+		if err != nil {
+			return 0, err
+		}
+	}
+	return f.resp.Read(p)
+}
+
+func (f *fakeDaemon) Close() error {
+	return nil
+}
+
+func (f *fakeDaemon) serve() error {
+	if f.v2 {
+		f.req.Next(5)
+	}
+	zr, err := zlib.NewReader(&f.req)
+	if err != nil {
+		return err
+	}
+	var payload rencode.List
+	err = rencode.NewDecoder(zr).Scan(&payload)
+	if err != nil {
+		return err
+	}
+	f.req.Reset()
+
+	var (
+		call   rencode.List
+		serial int64
+		method string
+		args   rencode.List
+		kwargs rencode.Dictionary
+	)
+	err = payload.Scan(&call)
+	if err != nil {
+		return err
+	}
+	err = call.Scan(&serial, &method, &args, &kwargs)
+	if err != nil {
+		return err
+	}
+
+	var msg rencode.List
+	value, err := f.handler(method, args, kwargs)
+	if err != nil {
+		rpcErr, ok := err.(RPCError)
+		if !ok {
+			return err
+		}
+		if f.v2 {
+			msg = rencode.NewList(int(rpcError), serial, rpcErr.ExceptionType, rencode.NewList(rpcErr.ExceptionMessage), rencode.Dictionary{}, rpcErr.TraceBack)
+		} else {
+			msg = rencode.NewList(int(rpcError), serial, rencode.NewList(rpcErr.ExceptionType, rpcErr.ExceptionMessage, rpcErr.TraceBack))
+		}
+	} else {
+		msg = rencode.NewList(int(rpcResponse), serial, value)
+	}
+
+	var body bytes.Buffer
+	zw := zlib.NewWriter(&body)
+	enc := rencode.NewEncoder(zw)
+	err = enc.Encode(msg)
+	if err != nil {
+		return err
+	}
+	err = zw.Close()
+	if err != nil {
+		return err
+	}
+
+	if f.v2 {
+		var header [5]byte
+		header[0] = Deluge2ProtocolVersion
+		binary.BigEndian.PutUint32(header[1:], uint32(body.Len()))
+		f.resp.Write(header[:])
+	}
+	_, err = f.resp.ReadFrom(&body)
+	return err
+}
+
+func newFakeClient(handler fakeHandler) *Client {
+	c := NewV1(Settings{})
+	c.safeConn = &fakeDaemon{handler: handler}
+	return c
+}
+
+func newFakeClientV2(handler fakeHandler) *ClientV2 {
+	c := NewV2(Settings{})
+	c.safeConn = &fakeDaemon{v2: true, handler: handler}
+	return c
+}
@@ -0,0 +1,175 @@
+// go-libdeluge v0.5.6 - a native deluge RPC client library
+// Copyright (C) 2015~2023 gdm85 - https://github.com/gdm85/go-libdeluge/
+// This program is free software; you can redistribute it and/or
+// modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; either version 2
+// of the License, or (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// You should have received a copy of the GNU General Public License
+// along with this program; if not, write to the Free Software
+// Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+
+package deluge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrStorageNotMoved is reported for a torrent whose download location is not the
+	// destination after moving its storage.
+	ErrStorageNotMoved = errors.New("storage not moved")
+	// ErrTorrentNotFound is reported for a torrent which is not in the session.
+	ErrTorrentNotFound = errors.New("torrent not found")
+)
+
+// movePendingPolls is the number of polls after which a torrent which was never seen in
+// the Moving state and is not at the destination is considered failed on v2 daemons, which
+// do not report moves which could not be started.
+const movePendingPolls = 3
+
+// MoveResult is the outcome of moving the storage of a torrent.
+type MoveResult struct {
+	ID string
+	// DownloadLocation is the download location after the move.
+	DownloadLocation string
+	// Err is set when the storage could not be moved, see ErrStorageNotMoved.
+	Err error
+}
+
+// MoveStorageAndWait moves the storage of the torrents with the given IDs to dest and then
+// waits for the moves to finish, see WaitMoveStorage. When resume is true the torrents
+// moved successfully are resumed afterwards.
+func (c *Client) MoveStorageAndWait(ctx context.Context, ids []string, dest string, interval time.Duration, resume bool) ([]MoveResult, error) {
+	err := c.MoveStorage(ctx, ids, dest)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := c.WaitMoveStorage(ctx, ids, dest, interval)
+	if err != nil {
+		return results, err
+	}
+
+	if !resume {
+		return results, nil
+	}
+
+	var moved []string
+	for _, r := range results {
+		if r.Err == nil {
+			moved = append(moved, r.ID)
+		}
+	}
+	if len(moved) != 0 {
+		err = c.ResumeTorrents(ctx, moved...)
+		if err != nil {
+			return results, err
+		}
+	}
+
+	return results, nil
+}
+
+// WaitMoveStorage polls the torrents with the given IDs every interval until none of them
+// is in the Moving state anymore, and returns a result for each of them in the same order.
+// A torrent whose download location is not dest once it left the Moving state, or which
+// ended in the Error state, gets an ErrStorageNotMoved error.
+// v1 daemons have no Moving state and only update the download location once the move
+// finished, therefore their torrents are waited for until they are at dest or in the Error
+// state; use the context to limit the wait.
+// When the context is done or the daemon cannot be reached, the results are returned
+// together with the error, which is wrapped by the Err of the torrents still pending.
+func (c *Client) WaitMoveStorage(ctx context.Context, ids []string, dest string, interval time.Duration) ([]MoveResult, error) {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+
+	results := make([]MoveResult, len(ids))
+	done := make([]bool, len(ids))
+	seenMoving := make([]bool, len(ids))
+	for i, id := range ids {
+		results[i].ID = id
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// unfinished reports the error for the torrents still pending
+	unfinished := func(err error) ([]MoveResult, error) {
+		for i := range results {
+			if !done[i] {
+				results[i].Err = fmt.Errorf("move not finished: %w", err)
+			}
+		}
+		return results, err
+	}
+
+	for polls := 1; ; polls++ {
+		select {
+		case <-ctx.Done():
+			return unfinished(ctx.Err())
+		case <-ticker.C:
+		}
+
+		torrents, err := c.TorrentsStatusFiltered(ctx, TorrentFilter{IDs: ids}, WithFields(FieldState, FieldDownloadLocation))
+		if err != nil {
+			return unfinished(err)
+		}
+
+		pending := false
+		for i, id := range ids {
+			if done[i] {
+				continue
+			}
+
+			ts, ok := torrents[id]
+			if !ok {
+				results[i].Err = ErrTorrentNotFound
+				done[i] = true
+				continue
+			}
+			results[i].DownloadLocation = ts.DownloadLocation
+
+			switch {
+			case TorrentState(ts.State) == StateMoving:
+				seenMoving[i] = true
+				pending = true
+				continue
+			case TorrentState(ts.State) == StateError:
+				results[i].Err = fmt.Errorf("%w: torrent in error state at %q", ErrStorageNotMoved, ts.DownloadLocation)
+			case samePath(ts.DownloadLocation, dest):
+			case !c.v2daemon || (!seenMoving[i] && polls < movePendingPolls):
+				// the move might still be running on v1, or not have started yet on v2
+				pending = true
+				continue
+			default:
+				results[i].Err = fmt.Errorf("%w: download location is %q", ErrStorageNotMoved, ts.DownloadLocation)
+			}
+			done[i] = true
+		}
+
+		if !pending {
+			return results, nil
+		}
+	}
+}
+
+// samePath compares two daemon paths ignoring trailing separators.
+func samePath(a, b string) bool {
+	trim := func(p string) string {
+		t := strings.TrimRight(p, `/\`)
+		if t == "" {
+			return p
+		}
+		return t
+	}
+	return trim(a) == trim(b)
+}
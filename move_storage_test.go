@@ -0,0 +1,189 @@
+package deluge
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gdm85/go-rencode"
+)
+
+// fakeMoveHandler serves torrents whose state and location change at each poll.
+func fakeMoveHandler(t *testing.T, v2 bool, polls [][]map[string]string, resumed *[]string) fakeHandler {
+	n := 0
+	return func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		switch method {
+		case "core.move_storage":
+			return nil, nil
+		case "core.resume_torrents", "core.resume_torrent":
+			ids := normalizeRencode(args).([]interface{})[0].([]interface{})
+			for _, id := range ids {
+				*resumed = append(*resumed, id.(string))
+			}
+			return nil, nil
+		case "core.get_torrents_status":
+			keys := normalizeRencode(args).([]interface{})[1]
+			locationKey := "download_location"
+			if !v2 {
+				locationKey = "save_path"
+			}
+			if !reflect.DeepEqual(keys, []interface{}{"state", locationKey}) {
+				t.Errorf("got keys %v", keys)
+			}
+
+			poll := polls[n]
+			if n < len(polls)-1 {
+				n++
+			}
+			var d rencode.Dictionary
+			for _, ts := range poll {
+				var st rencode.Dictionary
+				st.Add("state", ts["state"])
+				st.Add(locationKey, ts["location"])
+				d.Add(ts["id"], st)
+			}
+			return d, nil
+		}
+		return nil, RPCError{ExceptionType: "AttributeError", ExceptionMessage: method}
+	}
+}
+
+func TestMoveStorageAndWait(t *testing.T) {
+	t.Parallel()
+
+	polls := [][]map[string]string{
+		{
+			{"id": "a", "state": "Moving", "location": "/old"},
+			{"id": "b", "state": "Moving", "location": "/old"},
+			{"id": "c", "state": "Paused", "location": "/new/"},
+		},
+		{
+			{"id": "a", "state": "Paused", "location": "/new"},
+			{"id": "b", "state": "Paused", "location": "/old"},
+			{"id": "c", "state": "Paused", "location": "/new/"},
+		},
+	}
+	var resumed []string
+	c := newFakeClientV2(fakeMoveHandler(t, true, polls, &resumed))
+
+	results, err := c.MoveStorageAndWait(context.Background(), []string{"a", "b", "c", "d"}, "/new", time.Millisecond, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 4 {
+		t.Fatalf("got %d results", len(results))
+	}
+	if results[0].Err != nil || results[0].DownloadLocation != "/new" {
+		t.Errorf("unexpected result %+v", results[0])
+	}
+	if !errors.Is(results[1].Err, ErrStorageNotMoved) {
+		t.Errorf("unexpected result %+v", results[1])
+	}
+	if results[2].Err != nil {
+		t.Errorf("unexpected result %+v", results[2])
+	}
+	if !errors.Is(results[3].Err, ErrTorrentNotFound) {
+		t.Errorf("unexpected result %+v", results[3])
+	}
+	if !reflect.DeepEqual(resumed, []string{"a", "c"}) {
+		t.Errorf("got resumed torrents %v", resumed)
+	}
+}
+
+func TestWaitMoveStorageNotStarted(t *testing.T) {
+	t.Parallel()
+
+	polls := [][]map[string]string{
+		{{"id": "a", "state": "Seeding", "location": "/old"}},
+	}
+	var resumed []string
+	c := newFakeClientV2(fakeMoveHandler(t, true, polls, &resumed))
+
+	results, err := c.MoveStorageAndWait(context.Background(), []string{"a"}, "/new", time.Millisecond, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !errors.Is(results[0].Err, ErrStorageNotMoved) || results[0].DownloadLocation != "/old" {
+		t.Errorf("unexpected result %+v", results[0])
+	}
+	if len(resumed) != 0 {
+		t.Errorf("got resumed torrents %v", resumed)
+	}
+}
+
+func TestWaitMoveStorageError(t *testing.T) {
+	t.Parallel()
+
+	polls := [][]map[string]string{
+		{{"id": "a", "state": "Error", "location": "/old"}},
+	}
+	var resumed []string
+	c := newFakeClientV2(fakeMoveHandler(t, true, polls, &resumed))
+
+	results, err := c.WaitMoveStorage(context.Background(), []string{"a"}, "/new", time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(results[0].Err, ErrStorageNotMoved) {
+		t.Errorf("unexpected result %+v", results[0])
+	}
+}
+
+func TestWaitMoveStorageV1(t *testing.T) {
+	t.Parallel()
+
+	// v1 has no Moving state and the location changes once the move finished
+	var polls [][]map[string]string
+	for i := 0; i < movePendingPolls*2; i++ {
+		polls = append(polls, []map[string]string{{"id": "a", "state": "Seeding", "location": "/old"}})
+	}
+	polls = append(polls, []map[string]string{{"id": "a", "state": "Seeding", "location": "/new"}})
+	var resumed []string
+	c := newFakeClient(fakeMoveHandler(t, false, polls, &resumed))
+
+	results, err := c.MoveStorageAndWait(context.Background(), []string{"a"}, "/new", time.Millisecond, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if results[0].Err != nil || results[0].DownloadLocation != "/new" {
+		t.Errorf("unexpected result %+v", results[0])
+	}
+	if !reflect.DeepEqual(resumed, []string{"a"}) {
+		t.Errorf("got resumed torrents %v", resumed)
+	}
+}
+
+func TestWaitMoveStorageV1Timeout(t *testing.T) {
+	t.Parallel()
+
+	polls := [][]map[string]string{
+		{
+			{"id": "a", "state": "Seeding", "location": "/old"},
+			{"id": "b", "state": "Seeding", "location": "/new"},
+		},
+	}
+	var resumed []string
+	c := newFakeClient(fakeMoveHandler(t, false, polls, &resumed))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+
+	results, err := c.WaitMoveStorage(ctx, []string{"a", "b"}, "/new", time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if !errors.Is(results[0].Err, context.DeadlineExceeded) || results[0].DownloadLocation != "/old" {
+		t.Errorf("unexpected result %+v", results[0])
+	}
+	if results[1].Err != nil {
+		t.Errorf("unexpected result %+v", results[1])
+	}
+}
@@ -15,84 +15,137 @@
 package deluge
 
 import (
+	"errors"
+	"fmt"
 	"reflect"
+	"sort"
 
 	"github.com/gdm85/go-rencode"
 )
 
-// Options used when adding a torrent magnet/URL.
+// ErrUnsupportedOption is returned when an option is not supported by the version of the connected daemon.
+var ErrUnsupportedOption = errors.New("option not supported by the daemon version")
+
+// Options used when adding a torrent magnet/URL/file or when changing the options of a torrent.
+// Each field lists the Deluge versions supporting it; fields set for a daemon not supporting
+// them are reported with ErrUnsupportedOption.
 // Valid options for v2: https://github.com/deluge-torrent/deluge/blob/deluge-2.0.3/deluge/core/torrent.py#L167-L183
 // Valid options for v1: https://github.com/deluge-torrent/deluge/blob/1.3-stable/deluge/core/torrent.py#L83-L96
 type Options struct {
-	MaxConnections            *int
-	MaxUploadSlots            *int
-	MaxUploadSpeed            *int
-	MaxDownloadSpeed          *int
-	PrioritizeFirstLastPieces *bool
-	PreAllocateStorage        *bool   // v2-only but automatically converted to compact_allocation for v1
-	DownloadLocation          *string // works for both v1 and v2 when sending options
-	AutoManaged               *bool
-	StopAtRatio               *bool
-	StopRatio                 *float32
-	RemoveAtRatio             *float32
-	MoveCompleted             *bool
-	MoveCompletedPath         *string
-	AddPaused                 *bool
+	MaxConnections            *int     // 1.3, 2.0, 2.1
+	MaxUploadSlots            *int     // 1.3, 2.0, 2.1
+	MaxUploadSpeed            *int     // 1.3, 2.0, 2.1
+	MaxDownloadSpeed          *int     // 1.3, 2.0, 2.1
+	PrioritizeFirstLastPieces *bool    // 1.3, 2.0, 2.1
+	PreAllocateStorage        *bool    `rencode:"v2only"` // 2.0, 2.1; not the compact allocation of 1.3
+	CompactAllocation         *bool    `rencode:"v1only"` // 1.3; replaced by the full allocation of 2.0
+	DownloadLocation          *string  // 1.3, 2.0, 2.1
+	AutoManaged               *bool    // 1.3, 2.0, 2.1
+	StopAtRatio               *bool    // 1.3, 2.0, 2.1; pauses the torrent once StopRatio is reached
+	StopRatio                 *float32 // 1.3, 2.0, 2.1
+	RemoveAtRatio             *bool    // 1.3, 2.0, 2.1; removes instead of pausing, with StopAtRatio
+	MoveCompleted             *bool    // 1.3, 2.0, 2.1
+	MoveCompletedPath         *string  // 1.3, 2.0, 2.1
+	// AddPaused is only applied when adding a torrent, by both v1 and v2 daemons.
+	AddPaused *bool // 1.3, 2.0, 2.1
+	// FilePriorities sets the priority of each file, in the same order as the torrent files
+	FilePriorities []FilePriority // 1.3, 2.0, 2.1
+	// MappedFiles renames files by index when adding a torrent, e.g. {0: "dir/new name.mkv"}.
+	MappedFiles map[int]string // 1.3, 2.0, 2.1
 
 	// V2 defines v2-only options
-	V2 V2Options
+	V2 V2Options `rencode:"v2only"`
 }
 
+// V2Options are the options supported by Deluge 2.0 and 2.1 only.
 type V2Options struct {
-	SequentialDownload *bool
-	Shared             *bool
-	SuperSeeding       *bool
-	SeedMode           *bool
+	SequentialDownload *bool // 2.0, 2.1
+	Shared             *bool // 2.0, 2.1
+	SuperSeeding       *bool // 2.0, 2.1
+	// SeedMode skips the data check when adding a torrent whose files are complete.
+	// It is not a torrent option in 1.3, see TorrentOptions in
+	// https://github.com/deluge-torrent/deluge/blob/1.3-stable/deluge/core/torrent.py
+	SeedMode *bool // 2.0, 2.1
+	// Name renames the torrent when adding it.
+	Name *string // 2.0, 2.1
+	// Owner is the account owning the torrent; by default the one adding it.
+	Owner *string // 2.0, 2.1
 }
 
-func (o *Options) toDictionary(v2daemon bool) rencode.Dictionary {
-	var dict rencode.Dictionary
-	if o == nil {
-		return dict
-	}
+// optionField is an Options field with its option key.
+type optionField struct {
+	key   string
+	value reflect.Value
+	// tag is "v1only", "v2only" or empty for the options supported by both versions.
+	tag string
+}
 
-	v := reflect.ValueOf(*o)
-	t := v.Type()
+// supported returns whether the option is supported by the daemon version.
+func (f optionField) supported(v2daemon bool) bool {
+	return f.tag != versionTagExcluded(v2daemon)
+}
 
+// optionFields returns the fields of o; the fields of V2 inherit its v2only tag.
+func optionFields(o *Options) []optionField {
+	var fields []optionField
+
+	v := reflect.ValueOf(o).Elem()
+	t := v.Type()
 	for i := 0; i < v.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("rencode")
 		f := v.Field(i)
-		if f.Kind() == reflect.Struct {
-			// there is a single struct fields, V2, which is conditionally parsed after this loop
+		if f.Kind() != reflect.Struct {
+			fields = append(fields, optionField{key: rencode.ToSnakeCase(t.Field(i).Name), value: f, tag: tag})
 			continue
 		}
-		if f.IsNil() {
-			continue
+		for j := 0; j < f.NumField(); j++ {
+			fields = append(fields, optionField{key: rencode.ToSnakeCase(f.Type().Field(j).Name), value: f.Field(j), tag: tag})
 		}
-
-		name := rencode.ToSnakeCase(t.Field(i).Name)
-		if !v2daemon && name == "pre_allocate_storage" {
-			name = "compact_allocation"
-		}
-
-		dict.Add(name, reflect.Indirect(f).Interface())
 	}
 
-	if !v2daemon {
-		return dict
+	return fields
+}
+
+func (o *Options) toDictionary(v2daemon bool) (rencode.Dictionary, error) {
+	var dict rencode.Dictionary
+	if o == nil {
+		return dict, nil
 	}
 
-	// add the v2-only fields
-	v = reflect.ValueOf(o.V2)
-	t = v.Type()
-	for i := 0; i < v.NumField(); i++ {
-		f := v.Field(i)
-		if f.IsNil() {
+	for _, f := range optionFields(o) {
+		if f.value.IsNil() {
 			continue
 		}
+		if !f.supported(v2daemon) {
+			if v2daemon {
+				return rencode.Dictionary{}, fmt.Errorf("%w: %s was removed in Deluge 2.0", ErrUnsupportedOption, f.key)
+			}
+			return rencode.Dictionary{}, fmt.Errorf("%w: %s requires Deluge 2.0 or later", ErrUnsupportedOption, f.key)
+		}
 
-		name := rencode.ToSnakeCase(t.Field(i).Name)
-		dict.Add(name, reflect.Indirect(f).Interface())
+		switch f.key {
+		case "file_priorities":
+			dict.Add(f.key, filePrioritiesToList(o.FilePriorities, v2daemon))
+		case "mapped_files":
+			dict.Add(f.key, mappedFilesToDictionary(o.MappedFiles))
+		default:
+			dict.Add(f.key, reflect.Indirect(f.value).Interface())
+		}
 	}
 
+	return dict, nil
+}
+
+func mappedFilesToDictionary(mapped map[int]string) rencode.Dictionary {
+	indexes := make([]int, 0, len(mapped))
+	for index := range mapped {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	var dict rencode.Dictionary
+	for _, index := range indexes {
+		dict.Add(int64(index), mapped[index])
+	}
 	return dict
 }
@@ -15,7 +15,12 @@
 package deluge
 
 import (
+	"context"
+	"errors"
+	"reflect"
 	"testing"
+
+	"github.com/gdm85/go-rencode"
 )
 
 var testOpts Options
@@ -34,7 +39,10 @@ func init() {
 func TestNilOptionsEncode(t *testing.T) {
 	t.Parallel()
 	var o *Options
-	d := o.toDictionary(false)
+	d, err := o.toDictionary(false)
+	if err != nil {
+		t.Fatal(err)
+	}
 	if d.Length() != 0 {
 		t.Error("expected an empty dictionary")
 	}
@@ -45,7 +53,13 @@ func TestDefaultEncode(t *testing.T) {
 	fVal := false
 	testOptsWithDefaults.StopAtRatio = &fVal
 
-	d := testOptsWithDefaults.toDictionary(false)
+	testOptsWithDefaults.PreAllocateStorage = nil
+	testOptsWithDefaults.V2 = V2Options{}
+
+	d, err := testOptsWithDefaults.toDictionary(false)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	m, err := d.Zip()
 	if err != nil {
@@ -60,15 +74,21 @@ func TestDefaultEncode(t *testing.T) {
 func TestOptionsEncodeV1(t *testing.T) {
 	t.Parallel()
 
-	d := testOpts.toDictionary(false)
+	o := testOpts
+	o.PreAllocateStorage = nil
+	o.V2 = V2Options{}
+	d, err := o.toDictionary(false)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	m, err := d.Zip()
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	if _, ok := m["compact_allocation"]; !ok {
-		t.Errorf("expected key %q not found", "compact_allocation")
+	if _, ok := m["compact_allocation"]; ok {
+		t.Errorf("unexpected key %q found", "compact_allocation")
 	}
 
 	if _, ok := m["download_location"]; !ok {
@@ -79,10 +99,6 @@ func TestOptionsEncodeV1(t *testing.T) {
 		t.Errorf("unexpected key %q found", "save_path")
 	}
 
-	if _, ok := m["shared"]; ok {
-		t.Errorf("unexpected key %q found", "shared")
-	}
-
 	// a field never specified should not be encoded
 	if _, ok := m["max_upload_slots"]; ok {
 		t.Errorf("unexpected key %q found", "max_upload_slots")
@@ -93,7 +109,10 @@ func TestOptionsEncodeV1(t *testing.T) {
 func TestOptionsEncodeV2(t *testing.T) {
 	t.Parallel()
 
-	d := testOpts.toDictionary(true)
+	d, err := testOpts.toDictionary(true)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	m, err := d.Zip()
 	if err != nil {
@@ -127,3 +146,124 @@ func TestOptionsEncodeV2(t *testing.T) {
 
 	}
 }
+
+func TestFilePrioritiesEncode(t *testing.T) {
+	t.Parallel()
+
+	o := Options{
+		FilePriorities: []FilePriority{FilePrioritySkip, FilePriorityLow, FilePriorityNormal, FilePriorityHigh},
+	}
+
+	for _, tt := range []struct {
+		v2daemon bool
+		expected []interface{}
+	}{
+		{false, []interface{}{int64(0), int64(1), int64(1), int64(2)}},
+		{true, []interface{}{int64(0), int64(1), int64(4), int64(7)}},
+	} {
+		d, err := o.toDictionary(tt.v2daemon)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := d.Zip()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		l, ok := m["file_priorities"].(rencode.List)
+		if !ok {
+			t.Fatalf("expected key %q not found", "file_priorities")
+		}
+		if !reflect.DeepEqual(l.Values(), tt.expected) {
+			t.Errorf("v2daemon=%v: expected %v, got %v", tt.v2daemon, tt.expected, l.Values())
+		}
+	}
+}
+
+func TestOptionsUnsupportedV1(t *testing.T) {
+	t.Parallel()
+
+	tVal := true
+	name := "renamed"
+	for _, o := range []Options{
+		{PreAllocateStorage: &tVal},
+		{V2: V2Options{Shared: &tVal}},
+		{V2: V2Options{SeedMode: &tVal}},
+		{V2: V2Options{SuperSeeding: &tVal}},
+		{V2: V2Options{Name: &name}},
+	} {
+		_, err := o.toDictionary(false)
+		if !errors.Is(err, ErrUnsupportedOption) {
+			t.Errorf("got error %v for %+v", err, o)
+		}
+
+		_, err = o.toDictionary(true)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestOptionsUnsupportedV2(t *testing.T) {
+	t.Parallel()
+
+	tVal := true
+	o := Options{CompactAllocation: &tVal}
+	_, err := o.toDictionary(true)
+	if !errors.Is(err, ErrUnsupportedOption) {
+		t.Errorf("got error %v for %+v", err, o)
+	}
+
+	dict, err := o.toDictionary(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := dict.Get("compact_allocation"); !ok || v != true {
+		t.Errorf("unexpected compact_allocation %v", v)
+	}
+}
+
+func TestOptionsEncodeTypes(t *testing.T) {
+	t.Parallel()
+
+	tVal := true
+	o := Options{
+		StopAtRatio:   &tVal,
+		RemoveAtRatio: &tVal,
+		MappedFiles:   map[int]string{2: "b.mkv", 0: "a.mkv"},
+	}
+
+	d, err := o.toDictionary(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := normalizeRencode(d).(map[string]interface{})
+
+	if m["remove_at_ratio"] != true {
+		t.Errorf("got remove_at_ratio %v", m["remove_at_ratio"])
+	}
+	expected := map[string]interface{}{"0": "a.mkv", "2": "b.mkv"}
+	if !reflect.DeepEqual(m["mapped_files"], expected) {
+		t.Errorf("got mapped_files %v", m["mapped_files"])
+	}
+	mapped := d.Values()[2].(rencode.Dictionary)
+	keys := mapped.Keys()
+	if !reflect.DeepEqual(keys, []interface{}{int64(0), int64(2)}) {
+		t.Errorf("got mapped_files keys %v", keys)
+	}
+}
+
+func TestSetTorrentOptionsUnsupported(t *testing.T) {
+	t.Parallel()
+
+	c := newFakeClient(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		t.Errorf("unexpected call to %s", method)
+		return nil, nil
+	})
+
+	tVal := true
+	err := c.SetTorrentOptions(context.Background(), testMagnetHash, &Options{V2: V2Options{SuperSeeding: &tVal}})
+	if !errors.Is(err, ErrUnsupportedOption) {
+		t.Errorf("got error %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+// go-libdeluge v0.5.6 - a native deluge RPC client library
+// Copyright (C) 2015~2023 gdm85 - https://github.com/gdm85/go-libdeluge/
+// This program is free software; you can redistribute it and/or
+// modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; either version 2
+// of the License, or (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// You should have received a copy of the GNU General Public License
+// along with this program; if not, write to the Free Software
+// Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+
+package deluge
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/gdm85/go-rencode"
+)
+
+var (
+	// ErrPluginNotAvailable is returned by InstallPlugin when the uploaded plugin is not found by the daemon.
+	ErrPluginNotAvailable = errors.New("plugin not available after upload")
+	// ErrPluginNotEnabled is returned by InstallPlugin when the plugin could not be enabled.
+	ErrPluginNotEnabled = errors.New("plugin not enabled")
+)
+
+// UploadPlugin uploads a plugin egg to the plugins directory of the daemon,
+// which then scans for new plugins.
+func (c *Client) UploadPlugin(ctx context.Context, filename string, egg io.Reader) error {
+	data, err := io.ReadAll(egg)
+	if err != nil {
+		return err
+	}
+
+	var args rencode.List
+	args.Add(filename, base64.StdEncoding.EncodeToString(data))
+
+	return c.rpcWithNoResult(ctx, "core.upload_plugin", args, rencode.Dictionary{})
+}
+
+// RescanPlugins makes the daemon scan its plugins directories for new plugins.
+func (c *Client) RescanPlugins(ctx context.Context) error {
+	return c.rpcWithNoResult(ctx, "core.rescan_plugins", rencode.List{}, rencode.Dictionary{})
+}
+
+// InstallPlugin uploads the egg of the plugin with the given name, enables it and
+// verifies that it is listed by GetEnabledPlugins.
+// A plugin which was already enabled is disabled first so that the uploaded version is loaded.
+// On failure the plugin is rolled back to its previous enabled state; the uploaded egg
+// itself cannot be removed through RPC.
+func (c *Client) InstallPlugin(ctx context.Context, name, filename string, egg io.Reader) error {
+	enabled, err := c.GetEnabledPlugins(ctx)
+	if err != nil {
+		return err
+	}
+	wasEnabled := containsString(enabled, name)
+
+	if wasEnabled {
+		err = c.DisablePlugin(ctx, name)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = c.installPlugin(ctx, name, filename, egg)
+	if err == nil {
+		return nil
+	}
+
+	err = fmt.Errorf("install plugin %s: %w", name, err)
+	if wasEnabled {
+		rbErr := c.EnablePlugin(ctx, name)
+		if rbErr != nil {
+			return errors.Join(err, fmt.Errorf("re-enable plugin %s: %w", name, rbErr))
+		}
+		return err
+	}
+
+	// the plugin might have been enabled before the verification failed
+	enabled, rbErr := c.GetEnabledPlugins(ctx)
+	if rbErr == nil && containsString(enabled, name) {
+		rbErr = c.DisablePlugin(ctx, name)
+	}
+	if rbErr != nil {
+		return errors.Join(err, fmt.Errorf("disable plugin %s: %w", name, rbErr))
+	}
+
+	return err
+}
+
+func (c *Client) installPlugin(ctx context.Context, name, filename string, egg io.Reader) error {
+	err := c.UploadPlugin(ctx, filename, egg)
+	if err != nil {
+		return err
+	}
+
+	err = c.RescanPlugins(ctx)
+	if err != nil {
+		return err
+	}
+
+	available, err := c.GetAvailablePlugins(ctx)
+	if err != nil {
+		return err
+	}
+	if !containsString(available, name) {
+		return ErrPluginNotAvailable
+	}
+
+	err = c.EnablePlugin(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	enabled, err := c.GetEnabledPlugins(ctx)
+	if err != nil {
+		return err
+	}
+	if !containsString(enabled, name) {
+		return ErrPluginNotEnabled
+	}
+
+	return nil
+}
@@ -0,0 +1,190 @@
+package deluge
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gdm85/go-rencode"
+)
+
+// fakePluginDaemon keeps the plugin eggs uploaded to a fake daemon.
+type fakePluginDaemon struct {
+	eggs      map[string][]byte
+	available []string
+	enabled   []string
+	// broken plugins fail silently when enabled
+	broken map[string]bool
+	calls  []string
+}
+
+func newFakePluginDaemon() *fakePluginDaemon {
+	return &fakePluginDaemon{
+		eggs:      map[string][]byte{},
+		available: []string{"Label"},
+		enabled:   []string{"Label"},
+		broken:    map[string]bool{},
+	}
+}
+
+func (d *fakePluginDaemon) handle(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+	d.calls = append(d.calls, method)
+	vals := normalizeRencode(args).([]interface{})
+	switch method {
+	case "core.upload_plugin":
+		data, err := base64.StdEncoding.DecodeString(vals[1].(string))
+		if err != nil {
+			return nil, RPCError{ExceptionType: "binascii.Error", ExceptionMessage: err.Error()}
+		}
+		d.eggs[vals[0].(string)] = data
+		return nil, nil
+	case "core.rescan_plugins":
+		for filename := range d.eggs {
+			name := strings.SplitN(filename, "-", 2)[0]
+			if !containsString(d.available, name) {
+				d.available = append(d.available, name)
+			}
+		}
+		return nil, nil
+	case "core.get_available_plugins":
+		return sliceToRencodeList(d.available), nil
+	case "core.get_enabled_plugins":
+		return sliceToRencodeList(d.enabled), nil
+	case "core.enable_plugin":
+		name := vals[0].(string)
+		if !d.broken[name] && !containsString(d.enabled, name) {
+			d.enabled = append(d.enabled, name)
+		}
+		return true, nil
+	case "core.disable_plugin":
+		name := vals[0].(string)
+		var enabled []string
+		for _, p := range d.enabled {
+			if p != name {
+				enabled = append(enabled, p)
+			}
+		}
+		d.enabled = enabled
+		return true, nil
+	}
+	return nil, RPCError{ExceptionType: "AttributeError", ExceptionMessage: method}
+}
+
+func TestUploadPlugin(t *testing.T) {
+	t.Parallel()
+
+	d := newFakePluginDaemon()
+	c := newFakeClientV2(d.handle)
+
+	err := c.UploadPlugin(context.Background(), "Stats-0.4-py3.8.egg", strings.NewReader("egg content"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(d.eggs["Stats-0.4-py3.8.egg"]) != "egg content" {
+		t.Errorf("got egg %q", d.eggs["Stats-0.4-py3.8.egg"])
+	}
+}
+
+func TestInstallPlugin(t *testing.T) {
+	t.Parallel()
+
+	d := newFakePluginDaemon()
+	c := newFakeClientV2(d.handle)
+
+	err := c.InstallPlugin(context.Background(), "Stats", "Stats-0.4-py3.8.egg", strings.NewReader("egg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(d.enabled, []string{"Label", "Stats"}) {
+		t.Errorf("got enabled plugins %v", d.enabled)
+	}
+	expected := []string{
+		"core.get_enabled_plugins",
+		"core.upload_plugin",
+		"core.rescan_plugins",
+		"core.get_available_plugins",
+		"core.enable_plugin",
+		"core.get_enabled_plugins",
+	}
+	if !reflect.DeepEqual(d.calls, expected) {
+		t.Errorf("got calls %v", d.calls)
+	}
+}
+
+func TestInstallPluginUpgrade(t *testing.T) {
+	t.Parallel()
+
+	d := newFakePluginDaemon()
+	c := newFakeClient(d.handle)
+
+	err := c.InstallPlugin(context.Background(), "Label", "Label-0.3-py2.7.egg", strings.NewReader("egg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d.calls[1] != "core.disable_plugin" {
+		t.Errorf("enabled plugin not disabled before upload: %v", d.calls)
+	}
+	if !reflect.DeepEqual(d.enabled, []string{"Label"}) {
+		t.Errorf("got enabled plugins %v", d.enabled)
+	}
+}
+
+func TestInstallPluginNotAvailable(t *testing.T) {
+	t.Parallel()
+
+	d := newFakePluginDaemon()
+	c := newFakeClientV2(d.handle)
+
+	// the egg name does not match the plugin name
+	err := c.InstallPlugin(context.Background(), "Stats", "stats.egg", strings.NewReader("egg"))
+	if !errors.Is(err, ErrPluginNotAvailable) {
+		t.Fatalf("got error %v", err)
+	}
+	if !reflect.DeepEqual(d.enabled, []string{"Label"}) {
+		t.Errorf("got enabled plugins %v", d.enabled)
+	}
+}
+
+func TestInstallPluginRollback(t *testing.T) {
+	t.Parallel()
+
+	d := newFakePluginDaemon()
+	d.broken["Label"] = true
+	c := newFakeClientV2(d.handle)
+
+	err := c.InstallPlugin(context.Background(), "Label", "Label-0.3-py3.8.egg", strings.NewReader("egg"))
+	if !errors.Is(err, ErrPluginNotEnabled) {
+		t.Fatalf("got error %v", err)
+	}
+
+	// the previously enabled plugin is enabled again
+	if d.calls[len(d.calls)-1] != "core.enable_plugin" {
+		t.Errorf("plugin not re-enabled: %v", d.calls)
+	}
+}
+
+func TestInstallPluginUploadError(t *testing.T) {
+	t.Parallel()
+
+	d := newFakePluginDaemon()
+	c := newFakeClientV2(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		if method == "core.upload_plugin" {
+			return nil, RPCError{ExceptionType: "OSError", ExceptionMessage: "read-only file system"}
+		}
+		return d.handle(method, args, kwargs)
+	})
+
+	err := c.InstallPlugin(context.Background(), "Stats", "Stats-0.4-py3.8.egg", strings.NewReader("egg"))
+	var rpcErr RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.ExceptionType != "OSError" {
+		t.Fatalf("got error %v", err)
+	}
+	if containsString(d.calls, "core.enable_plugin") || containsString(d.calls, "core.disable_plugin") {
+		t.Errorf("unexpected calls %v", d.calls)
+	}
+}
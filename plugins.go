@@ -16,6 +16,8 @@ package deluge
 
 import (
 	"context"
+	"reflect"
+
 	"github.com/gdm85/go-rencode"
 )
 
@@ -44,7 +46,7 @@ func (c *Client) LabelPlugin(ctx context.Context) (*LabelPlugin, error) {
 
 // GetLabels returns a list of the available labels that can be assigned to torrents.
 func (p LabelPlugin) GetLabels(ctx context.Context) ([]string, error) {
-	return p.rpcWithStringsResult(nil, "label.get_labels")
+	return p.rpcWithStringsResult(ctx, "label.get_labels")
 }
 
 // SetTorrentLabel adds or replaces the label for the specified torrent.
@@ -159,3 +161,69 @@ func (p LabelPlugin) GetTorrentsLabels(state TorrentState, ids []string) (map[st
 
 	return result, nil
 }
+
+// LabelOptions are the options applied to the torrents of a label; nil fields
+// are not changed by SetLabelOptions.
+// Valid options: https://github.com/deluge-torrent/deluge/blob/deluge-2.0.3/deluge/plugins/Label/deluge_label/core.py#L37-L57
+type LabelOptions struct {
+	ApplyMax            *bool
+	MaxDownloadSpeed    *float32
+	MaxUploadSpeed      *float32
+	MaxConnections      *int
+	MaxUploadSlots      *int
+	PrioritizeFirstLast *bool
+	ApplyQueue          *bool
+	IsAutoManaged       *bool
+	StopAtRatio         *bool
+	StopRatio           *float32
+	RemoveAtRatio       *bool
+	ApplyMoveCompleted  *bool
+	MoveCompleted       *bool
+	MoveCompletedPath   *string
+	AutoAdd             *bool
+	AutoAddTrackers     []string
+}
+
+// values returns the normalized values of the non-nil fields.
+func (o *LabelOptions) values() map[string]interface{} {
+	result := map[string]interface{}{}
+	if o == nil {
+		return result
+	}
+	v := reflect.ValueOf(*o)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if v.Field(i).IsNil() {
+			continue
+		}
+		result[rencode.ToSnakeCase(t.Field(i).Name)] = normalizeRencode(toRencode(v.Field(i)))
+	}
+	return result
+}
+
+// GetLabelOptions returns the options of a label.
+func (p LabelPlugin) GetLabelOptions(ctx context.Context, label string) (*LabelOptions, error) {
+	var args rencode.List
+	args.Add(label)
+
+	rd, err := p.rpcWithDictionaryResult(ctx, "label.get_options", args, rencode.Dictionary{})
+	if err != nil {
+		return nil, err
+	}
+
+	var o LabelOptions
+	err = assignRencode(rd, reflect.ValueOf(&o).Elem())
+	if err != nil {
+		return nil, err
+	}
+
+	return &o, nil
+}
+
+// SetLabelOptions sets the non-nil options of a label.
+func (p LabelPlugin) SetLabelOptions(ctx context.Context, label string, options *LabelOptions) error {
+	var args rencode.List
+	args.Add(label, valuesToDictionary(options.values()))
+
+	return p.rpcWithNoResult(ctx, "label.set_options", args, rencode.Dictionary{})
+}
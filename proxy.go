@@ -0,0 +1,230 @@
+// go-libdeluge v0.5.6 - a native deluge RPC client library
+// Copyright (C) 2015~2023 gdm85 - https://github.com/gdm85/go-libdeluge/
+// This program is free software; you can redistribute it and/or
+// modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; either version 2
+// of the License, or (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// You should have received a copy of the GNU General Public License
+// along with this program; if not, write to the Free Software
+// Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+
+package deluge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/gdm85/go-rencode"
+)
+
+// ProxyType is the kind of proxy used by the daemon.
+type ProxyType int
+
+// The proxy types, as defined in
+// https://github.com/deluge-torrent/deluge/blob/deluge-2.0.3/deluge/core/preferencesmanager.py#L411-L433
+const (
+	ProxyNone ProxyType = iota
+	ProxySocks4
+	ProxySocks5
+	ProxySocks5Auth
+	ProxyHTTP
+	ProxyHTTPAuth
+	// ProxyI2P is v2-only.
+	ProxyI2P
+)
+
+var proxyTypeNames = []string{"none", "socks4", "socks5", "socks5-auth", "http", "http-auth", "i2p"}
+
+func (t ProxyType) String() string {
+	if t < 0 || int(t) >= len(proxyTypeNames) {
+		return fmt.Sprintf("ProxyType(%d)", int(t))
+	}
+	return proxyTypeNames[t]
+}
+
+// v1ProxyKinds are the connections with their own proxy settings on v1 daemons.
+var v1ProxyKinds = []string{"peer", "web_seed", "tracker", "dht"}
+
+var (
+	// ErrProxyMismatch is returned by SetProxyAndVerify when the proxy settings
+	// read back differ from the ones set.
+	ErrProxyMismatch = errors.New("proxy settings not applied")
+)
+
+// ProxyConfig is the proxy section of the daemon configuration.
+// On v1 daemons, which have separate settings per connection kind, the peer proxy
+// is used for peers, web seeds and DHT and the tracker proxy for trackers.
+type ProxyConfig struct {
+	Type     ProxyType
+	Hostname string
+	Port     int
+	Username string
+	Password string
+
+	ProxyPeerConnections    bool
+	ProxyTrackerConnections bool
+
+	ProxyHostnames bool `rencode:"v2only"`
+	ForceProxy     bool `rencode:"v2only"`
+	AnonymousMode  bool `rencode:"v2only"`
+}
+
+// v1Proxy is the proxy of a single connection kind on v1 daemons.
+type v1Proxy struct {
+	Type     ProxyType
+	Hostname string
+	Username string
+	Password string
+	Port     int
+}
+
+func (p ProxyConfig) validate(v2daemon bool) error {
+	if p.Type < ProxyNone || p.Type > ProxyI2P {
+		return fmt.Errorf("invalid proxy type %d", int(p.Type))
+	}
+	if p.Port < 0 || p.Port > 65535 {
+		return fmt.Errorf("invalid proxy port %d", p.Port)
+	}
+	if p.Type == ProxyI2P && !v2daemon {
+		return errors.New("i2p proxy is not supported by v1 daemons")
+	}
+
+	excluded, daemon := versionTagExcluded(v2daemon), "v1"
+	if v2daemon {
+		daemon = "v2"
+	}
+	v := reflect.ValueOf(p)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("rencode") == excluded && !v.Field(i).IsZero() {
+			return fmt.Errorf("%s is not supported by %s daemons", rencode.ToSnakeCase(t.Field(i).Name), daemon)
+		}
+	}
+	return nil
+}
+
+// GetProxy returns the proxy settings.
+func (c *Client) GetProxy(ctx context.Context) (*ProxyConfig, error) {
+	if !c.v2daemon {
+		return c.getProxyV1(ctx)
+	}
+
+	rd, err := c.rpcWithDictionaryResult(ctx, "core.get_proxy", rencode.List{}, rencode.Dictionary{})
+	if err != nil {
+		return nil, err
+	}
+
+	var p ProxyConfig
+	err = assignRencode(rd, reflect.ValueOf(&p).Elem())
+	if err != nil {
+		return nil, err
+	}
+
+	// core.get_proxy does not return force_proxy and anonymous_mode
+	v, err := c.GetConfigValue(ctx, "proxy")
+	if err != nil {
+		return nil, err
+	}
+	var extra struct {
+		ForceProxy    bool
+		AnonymousMode bool
+	}
+	err = assignRencode(v, reflect.ValueOf(&extra).Elem())
+	if err != nil {
+		return nil, err
+	}
+	p.ForceProxy, p.AnonymousMode = extra.ForceProxy, extra.AnonymousMode
+
+	return &p, nil
+}
+
+func (c *Client) getProxyV1(ctx context.Context) (*ProxyConfig, error) {
+	v, err := c.GetConfigValue(ctx, "proxies")
+	if err != nil {
+		return nil, err
+	}
+
+	var proxies map[string]v1Proxy
+	err = assignRencode(v, reflect.ValueOf(&proxies).Elem())
+	if err != nil {
+		return nil, err
+	}
+
+	peer, tracker := proxies["peer"], proxies["tracker"]
+	p := ProxyConfig{
+		ProxyPeerConnections:    peer.Type != ProxyNone,
+		ProxyTrackerConnections: tracker.Type != ProxyNone,
+	}
+	primary := peer
+	if primary.Type == ProxyNone {
+		primary = tracker
+	}
+	p.Type, p.Hostname, p.Port = primary.Type, primary.Hostname, primary.Port
+	p.Username, p.Password = primary.Username, primary.Password
+
+	return &p, nil
+}
+
+// SetProxy replaces the proxy settings; an error is returned for settings not
+// supported by the daemon version.
+func (c *Client) SetProxy(ctx context.Context, proxy ProxyConfig) error {
+	err := proxy.validate(c.v2daemon)
+	if err != nil {
+		return err
+	}
+
+	var config rencode.Dictionary
+	if c.v2daemon {
+		config.Add("proxy", toRencode(reflect.ValueOf(proxy)))
+	} else {
+		config.Add("proxies", proxy.toV1())
+	}
+
+	var args rencode.List
+	args.Add(config)
+
+	return c.rpcWithNoResult(ctx, "core.set_config", args, rencode.Dictionary{})
+}
+
+func (p ProxyConfig) toV1() rencode.Dictionary {
+	var dict rencode.Dictionary
+	for _, kind := range v1ProxyKinds {
+		v := v1Proxy{Type: p.Type, Hostname: p.Hostname, Username: p.Username, Password: p.Password, Port: p.Port}
+		if (kind == "tracker" && !p.ProxyTrackerConnections) || (kind != "tracker" && !p.ProxyPeerConnections) {
+			v.Type = ProxyNone
+		}
+		dict.Add(kind, toRencode(reflect.ValueOf(v)))
+	}
+	return dict
+}
+
+// SetProxyAndVerify sets the proxy settings and reads them back, returning
+// ErrProxyMismatch if the daemon did not apply them.
+func (c *Client) SetProxyAndVerify(ctx context.Context, proxy ProxyConfig) error {
+	err := c.SetProxy(ctx, proxy)
+	if err != nil {
+		return err
+	}
+
+	current, err := c.GetProxy(ctx)
+	if err != nil {
+		return err
+	}
+
+	expected := proxy
+	if !c.v2daemon && !proxy.ProxyPeerConnections && !proxy.ProxyTrackerConnections {
+		// the proxy type is only kept per connection kind on v1 daemons
+		expected.Type = ProxyNone
+	}
+	if *current != expected {
+		return fmt.Errorf("%w: got type %s, %s:%d", ErrProxyMismatch, current.Type, current.Hostname, current.Port)
+	}
+
+	return nil
+}
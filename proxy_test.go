@@ -0,0 +1,115 @@
+package deluge
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gdm85/go-rencode"
+)
+
+func TestSetProxyAndVerify(t *testing.T) {
+	t.Parallel()
+
+	var stored interface{}
+	c := newFakeClientV2(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		switch method {
+		case "core.set_config":
+			config := normalizeRencode(args.Values()[0]).(map[string]interface{})
+			stored = config["proxy"]
+			return nil, nil
+		case "core.get_proxy":
+			// the real daemon returns only these keys
+			proxy := map[string]interface{}{}
+			for _, k := range []string{"type", "hostname", "username", "password", "port", "proxy_hostnames", "proxy_peer_connections", "proxy_tracker_connections"} {
+				proxy[k] = stored.(map[string]interface{})[k]
+			}
+			return valuesToDictionary(proxy), nil
+		case "core.get_config_value":
+			return valuesToDictionary(stored.(map[string]interface{})), nil
+		}
+		return nil, RPCError{ExceptionType: "AttributeError", ExceptionMessage: method}
+	})
+
+	proxy := ProxyConfig{
+		Type:                 ProxySocks5Auth,
+		Hostname:             "vpn.example.com",
+		Port:                 1080,
+		Username:             "user",
+		Password:             "pass",
+		ProxyPeerConnections: true,
+		ForceProxy:           true,
+		AnonymousMode:        true,
+	}
+	err := c.SetProxyAndVerify(context.Background(), proxy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.(map[string]interface{})["type"] != int64(ProxySocks5Auth) {
+		t.Errorf("unexpected stored proxy %v", stored)
+	}
+}
+
+func TestSetProxyAndVerifyMismatch(t *testing.T) {
+	t.Parallel()
+
+	c := newFakeClientV2(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		if method == "core.get_proxy" {
+			var d rencode.Dictionary
+			d.Add("type", 0)
+			return d, nil
+		}
+		return nil, nil
+	})
+
+	err := c.SetProxyAndVerify(context.Background(), ProxyConfig{Type: ProxyHTTP, Hostname: "proxy", Port: 3128})
+	if !errors.Is(err, ErrProxyMismatch) {
+		t.Errorf("expected mismatch, got %v", err)
+	}
+}
+
+func TestProxyV1(t *testing.T) {
+	t.Parallel()
+
+	var stored interface{}
+	c := newFakeClient(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		switch method {
+		case "core.set_config":
+			config := normalizeRencode(args.Values()[0]).(map[string]interface{})
+			stored = config["proxies"]
+			return nil, nil
+		case "core.get_config_value":
+			return valuesToDictionary(stored.(map[string]interface{})), nil
+		}
+		return nil, RPCError{ExceptionType: "AttributeError", ExceptionMessage: method}
+	})
+
+	proxy := ProxyConfig{
+		Type:                    ProxyHTTP,
+		Hostname:                "proxy",
+		Port:                    3128,
+		ProxyTrackerConnections: true,
+	}
+	err := c.SetProxyAndVerify(context.Background(), proxy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxies := stored.(map[string]interface{})
+	if proxies["peer"].(map[string]interface{})["type"] != int64(ProxyNone) {
+		t.Errorf("expected peer connections not to be proxied: %v", proxies)
+	}
+	if proxies["tracker"].(map[string]interface{})["type"] != int64(ProxyHTTP) {
+		t.Errorf("expected tracker connections to be proxied: %v", proxies)
+	}
+
+	err = c.SetProxy(context.Background(), ProxyConfig{Type: ProxyI2P})
+	if err == nil {
+		t.Error("expected error for i2p proxy on v1 daemon")
+	}
+
+	err = c.SetProxy(context.Background(), ProxyConfig{Type: ProxyHTTP, ForceProxy: true})
+	if err == nil {
+		t.Error("expected error for force proxy on v1 daemon")
+	}
+}
@@ -0,0 +1,576 @@
+// go-libdeluge v0.5.6 - a native deluge RPC client library
+// Copyright (C) 2015~2023 gdm85 - https://github.com/gdm85/go-libdeluge/
+// This program is free software; you can redistribute it and/or
+// modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; either version 2
+// of the License, or (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// You should have received a copy of the GNU General Public License
+// along with this program; if not, write to the Free Software
+// Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+
+package deluge
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdm85/go-rencode"
+)
+
+// Query is a parsed search over the torrent status, see ParseQuery.
+type Query struct {
+	Filter *Filter
+	// Sort lists the sort keys by priority; torrents are sorted by ID last.
+	Sort   []SortKey
+	Limit  int // zero for no limit
+	Offset int
+}
+
+// SortKey is a field to sort torrents by.
+type SortKey struct {
+	Field StatusField
+	Desc  bool
+}
+
+// QueryResult is a torrent returned by RunQuery.
+type QueryResult struct {
+	ID     string
+	Status *TorrentStatus
+}
+
+// QueryError is returned for invalid queries.
+type QueryError struct {
+	// Offset is the position in the query of the term in error.
+	Offset int
+	Term   string
+	Msg    string
+}
+
+func (e QueryError) Error() string {
+	return fmt.Sprintf("query: %s in %q at offset %d", e.Msg, e.Term, e.Offset)
+}
+
+// queryUnit tells how the values of a numeric field are parsed.
+type queryUnit int
+
+const (
+	unitNone queryUnit = iota
+	// unitDuration accepts e.g. "90", "45m", "14d" or "2w", in seconds
+	unitDuration
+	// unitSize accepts e.g. "1024", "700M", "1.5GiB" or "4GB", in bytes
+	unitSize
+	// unitTime accepts a date like "2023-01-31" or a RFC 3339 time, as a Unix time
+	unitTime
+)
+
+type queryField struct {
+	name  StatusField
+	index int // TorrentStatus field index, -1 for fields only evaluated by the daemon
+	kind  reflect.Kind
+	unit  queryUnit
+}
+
+var (
+	queryFields = buildQueryFields()
+
+	queryAliases = map[string]string{
+		"seed_time": "seeding_time",
+		"tracker":   "tracker_host",
+		"size":      "total_size",
+		"id":        "hash",
+		"added":     "time_added",
+		"completed": "completed_time",
+	}
+
+	queryUnits = map[StatusField]queryUnit{
+		FieldActiveTime:          unitDuration,
+		FieldSeedingTime:         unitDuration,
+		FieldETA:                 unitDuration,
+		FieldNextAnnounce:        unitDuration,
+		FieldTotalSize:           unitSize,
+		FieldTotalDone:           unitSize,
+		FieldPieceLength:         unitSize,
+		FieldDownloadPayloadRate: unitSize,
+		FieldUploadPayloadRate:   unitSize,
+		FieldTimeAdded:           unitTime,
+		FieldCompletedTime:       unitTime,
+		FieldLastSeenComplete:    unitTime,
+	}
+
+	queryDurationUnits = map[string]float64{"": 1, "s": 1, "m": 60, "h": 3600, "d": 86400, "w": 7 * 86400}
+
+	querySizeUnits = map[string]float64{
+		"": 1, "b": 1,
+		"k": 1 << 10, "kib": 1 << 10, "kb": 1e3,
+		"m": 1 << 20, "mib": 1 << 20, "mb": 1e6,
+		"g": 1 << 30, "gib": 1 << 30, "gb": 1e9,
+		"t": 1 << 40, "tib": 1 << 40, "tb": 1e12,
+	}
+
+	queryOperators = []string{"!=", ">=", "<=", "!~", ":", "=", ">", "<", "~"}
+)
+
+// buildQueryFields returns the TorrentStatus fields with a scalar type, plus the
+// fields known only to the daemon filter.
+func buildQueryFields() map[string]queryField {
+	fields := map[string]queryField{
+		"label": {name: "label", index: -1, kind: reflect.String},
+		"owner": {name: "owner", index: -1, kind: reflect.String},
+	}
+	t := reflect.TypeOf(TorrentStatus{})
+	for i := 0; i < t.NumField(); i++ {
+		kind := t.Field(i).Type.Kind()
+		switch kind {
+		case reflect.String, reflect.Bool, reflect.Int64, reflect.Float32:
+		default:
+			continue
+		}
+		name := StatusField(rencode.ToSnakeCase(t.Field(i).Name))
+		fields[string(name)] = queryField{name: name, index: i, kind: kind, unit: queryUnits[name]}
+	}
+	return fields
+}
+
+// ParseQuery parses a search made of space separated terms, which must all match:
+//
+//	state:Seeding label:tv ratio>2 seed_time>14d tracker:example.org name~"S01"
+//
+// A term is a field, an operator and a value; values with spaces are double quoted.
+// Operators are ":" and "=" (equality, case insensitive for text), "!=", "<", "<=",
+// ">", ">=", "~" and "!~" (regular expression match). A term without operator
+// matches the torrents whose name contains it.
+// Durations (seeding_time, active_time, eta) accept the s, m, h, d and w units,
+// sizes (total_size, total_done, rates) the K, M, G and T units and times
+// (time_added, completed_time) dates like 2023-01-31.
+// The special terms sort:field[,-field...] (a leading "-" sorts in descending
+// order), limit:N and offset:N control the order and pagination of the results.
+// State, label, tracker_host, owner and hash equality are evaluated by the daemon.
+func ParseQuery(s string) (*Query, error) {
+	q := &Query{Filter: NewFilter()}
+	seen := map[string]bool{}
+
+	pos := 0
+	for {
+		for pos < len(s) && s[pos] == ' ' {
+			pos++
+		}
+		if pos == len(s) {
+			break
+		}
+
+		start := pos
+		field, op, value, end, err := scanQueryTerm(s, pos)
+		if err != nil {
+			return nil, QueryError{Offset: start, Term: s[start:end], Msg: err.Error()}
+		}
+		pos = end
+
+		err = q.addTerm(field, op, value, seen)
+		if err != nil {
+			return nil, QueryError{Offset: start, Term: s[start:end], Msg: err.Error()}
+		}
+	}
+
+	return q, nil
+}
+
+// scanQueryTerm reads a term starting at pos and returns the offset after it.
+func scanQueryTerm(s string, pos int) (field, op, value string, end int, err error) {
+	i := pos
+	for i < len(s) && (s[i] == '_' || (s[i] >= 'a' && s[i] <= 'z') || (s[i] >= 'A' && s[i] <= 'Z') || (s[i] >= '0' && s[i] <= '9')) {
+		i++
+	}
+	for _, candidate := range queryOperators {
+		if i > pos && strings.HasPrefix(s[i:], candidate) {
+			field, op = strings.ToLower(s[pos:i]), candidate
+			i += len(candidate)
+			break
+		}
+	}
+	if op == "" {
+		// a bare word
+		i = pos
+	}
+
+	if i < len(s) && s[i] == '"' {
+		var sb strings.Builder
+		for i++; ; i++ {
+			if i >= len(s) {
+				return "", "", "", len(s), fmt.Errorf("unterminated quoted value")
+			}
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+			} else if s[i] == '"' {
+				i++
+				break
+			}
+			sb.WriteByte(s[i])
+		}
+		if i < len(s) && s[i] != ' ' {
+			return "", "", "", i, fmt.Errorf("missing space after quoted value")
+		}
+		return field, op, sb.String(), i, nil
+	}
+
+	end = strings.IndexByte(s[i:], ' ')
+	if end < 0 {
+		end = len(s)
+	} else {
+		end += i
+	}
+	value = s[i:end]
+	if op != "" && value == "" {
+		return "", "", "", end, fmt.Errorf("missing value")
+	}
+	return field, op, value, end, nil
+}
+
+func (q *Query) addTerm(name, op, value string, seen map[string]bool) error {
+	if op == "" {
+		lower := strings.ToLower(value)
+		q.Filter.Where(func(ts *TorrentStatus) bool {
+			return strings.Contains(strings.ToLower(ts.Name), lower)
+		}, FieldName)
+		return nil
+	}
+
+	switch name {
+	case "sort", "limit", "offset":
+		if op != ":" && op != "=" {
+			return fmt.Errorf("%s only supports ':'", name)
+		}
+		return q.addOption(name, value)
+	}
+
+	if alias, ok := queryAliases[name]; ok {
+		name = alias
+	}
+	f, ok := queryFields[name]
+	if !ok {
+		return unknownQueryField(name)
+	}
+
+	// equality on the fields known to the daemon filter is evaluated by the daemon,
+	// once per field since each key of the filter has a single value
+	if (op == ":" || op == "=") && !seen[name] {
+		switch f.name {
+		case FieldState:
+			q.Filter.State(TorrentState(queryStateName(value)))
+		case FieldTrackerHost:
+			q.Filter.TrackerHost(value)
+		case FieldHash:
+			q.Filter.IDs(strings.ToLower(value))
+		case "label":
+			if value == "" {
+				q.Filter.Unlabeled()
+			} else {
+				q.Filter.Label(strings.ToLower(value))
+			}
+		case "owner":
+			q.Filter.Owner(value)
+		}
+		if f.index < 0 || f.name == FieldState || f.name == FieldTrackerHost || f.name == FieldHash {
+			seen[name] = true
+			return nil
+		}
+	}
+	if f.index < 0 {
+		return fmt.Errorf("%s only supports a single ':' term", name)
+	}
+
+	predicate, err := f.predicate(op, value)
+	if err != nil {
+		return err
+	}
+	if f.name == FieldCompletedTime {
+		q.Filter.v2only = true
+	}
+	q.Filter.Where(predicate, f.name)
+
+	return nil
+}
+
+func (q *Query) addOption(name, value string) error {
+	if name == "sort" {
+		for _, key := range strings.Split(value, ",") {
+			desc := strings.HasPrefix(key, "-")
+			key = strings.ToLower(strings.TrimPrefix(key, "-"))
+			if alias, ok := queryAliases[key]; ok {
+				key = alias
+			}
+			f, ok := queryFields[key]
+			if !ok {
+				return unknownQueryField(key)
+			}
+			if f.index < 0 {
+				return fmt.Errorf("cannot sort by %s", key)
+			}
+			q.Sort = append(q.Sort, SortKey{Field: f.name, Desc: desc})
+		}
+		return nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return fmt.Errorf("%s must be a positive integer", name)
+	}
+	if name == "limit" {
+		q.Limit = n
+	} else {
+		q.Offset = n
+	}
+	return nil
+}
+
+// queryStateName returns the state with the same case used by the daemon.
+func queryStateName(value string) string {
+	for _, state := range []TorrentState{StateActive, StateAllocating, StateChecking, StateDownloading, StateSeeding, StatePaused, StateError, StateQueued, StateMoving} {
+		if strings.EqualFold(value, string(state)) {
+			return string(state)
+		}
+	}
+	return value
+}
+
+// predicate returns the local condition of a term.
+func (f queryField) predicate(op, value string) (func(*TorrentStatus) bool, error) {
+	field := func(ts *TorrentStatus) reflect.Value {
+		return reflect.ValueOf(ts).Elem().Field(f.index)
+	}
+
+	switch f.kind {
+	case reflect.String:
+		switch op {
+		case ":", "=":
+			return func(ts *TorrentStatus) bool { return strings.EqualFold(field(ts).String(), value) }, nil
+		case "!=":
+			return func(ts *TorrentStatus) bool { return !strings.EqualFold(field(ts).String(), value) }, nil
+		case "~", "!~":
+			re, err := regexp.Compile(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regular expression: %v", err)
+			}
+			negate := op == "!~"
+			return func(ts *TorrentStatus) bool { return re.MatchString(field(ts).String()) != negate }, nil
+		}
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s expects true or false", f.name)
+		}
+		switch op {
+		case ":", "=":
+			return func(ts *TorrentStatus) bool { return field(ts).Bool() == b }, nil
+		case "!=":
+			return func(ts *TorrentStatus) bool { return field(ts).Bool() != b }, nil
+		}
+	default:
+		n, err := parseQueryNumber(value, f.unit)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", f.name, err)
+		}
+		get := func(ts *TorrentStatus) float64 {
+			v := field(ts)
+			if f.kind == reflect.Float32 {
+				return v.Float()
+			}
+			return float64(v.Int())
+		}
+		switch op {
+		case ":", "=":
+			return func(ts *TorrentStatus) bool { return get(ts) == n }, nil
+		case "!=":
+			return func(ts *TorrentStatus) bool { return get(ts) != n }, nil
+		case ">":
+			return func(ts *TorrentStatus) bool { return get(ts) > n }, nil
+		case ">=":
+			return func(ts *TorrentStatus) bool { return get(ts) >= n }, nil
+		case "<":
+			return func(ts *TorrentStatus) bool { return get(ts) < n }, nil
+		case "<=":
+			return func(ts *TorrentStatus) bool { return get(ts) <= n }, nil
+		}
+	}
+
+	return nil, fmt.Errorf("operator %q is not supported by %s", op, f.name)
+}
+
+func parseQueryNumber(value string, unit queryUnit) (float64, error) {
+	if unit == unitTime {
+		for _, layout := range []string{"2006-01-02", time.RFC3339} {
+			t, err := time.Parse(layout, value)
+			if err == nil {
+				return float64(t.Unix()), nil
+			}
+		}
+		return 0, fmt.Errorf("invalid time %q, expected e.g. 2023-01-31", value)
+	}
+
+	i := len(value)
+	for i > 0 && (value[i-1] < '0' || value[i-1] > '9') && value[i-1] != '.' {
+		i--
+	}
+	n, err := strconv.ParseFloat(value[:i], 64)
+	suffix := strings.ToLower(value[i:])
+	var multiplier float64
+	var ok bool
+	switch unit {
+	case unitDuration:
+		multiplier, ok = queryDurationUnits[suffix]
+	case unitSize:
+		multiplier, ok = querySizeUnits[suffix]
+	default:
+		multiplier, ok = 1, suffix == ""
+	}
+	if err != nil || !ok {
+		return 0, fmt.Errorf("invalid value %q", value)
+	}
+
+	return n * multiplier, nil
+}
+
+// unknownQueryField returns an error suggesting the closest known field.
+func unknownQueryField(name string) error {
+	best, bestDistance := "", 3
+	candidates := make([]string, 0, len(queryFields)+len(queryAliases))
+	for k := range queryFields {
+		candidates = append(candidates, k)
+	}
+	for k := range queryAliases {
+		candidates = append(candidates, k)
+	}
+	sort.Strings(candidates)
+	for _, k := range candidates {
+		d := editDistance(name, k)
+		if d < bestDistance {
+			best, bestDistance = k, d
+		}
+	}
+	if best != "" {
+		return fmt.Errorf("unknown field %q, did you mean %q?", name, best)
+	}
+	return fmt.Errorf("unknown field %q", name)
+}
+
+// editDistance returns the Levenshtein distance between two strings.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(minInt(prev[j]+1, cur[j-1]+1), prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// less compares two results by the sort keys, then by ID.
+func (q *Query) less(a, b QueryResult) bool {
+	for _, key := range q.Sort {
+		f := queryFields[string(key.Field)]
+		va := reflect.ValueOf(a.Status).Elem().Field(f.index)
+		vb := reflect.ValueOf(b.Status).Elem().Field(f.index)
+		var cmp int
+		switch f.kind {
+		case reflect.String:
+			cmp = strings.Compare(strings.ToLower(va.String()), strings.ToLower(vb.String()))
+		case reflect.Bool:
+			cmp = boolToInt(va.Bool()) - boolToInt(vb.Bool())
+		case reflect.Float32:
+			cmp = compareFloat(va.Float(), vb.Float())
+		default:
+			cmp = compareFloat(float64(va.Int()), float64(vb.Int()))
+		}
+		if cmp != 0 {
+			return (cmp < 0) != key.Desc
+		}
+	}
+	return a.ID < b.ID
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// RunQuery returns a page of the torrents matching the query, sorted, along with
+// the total number of matching torrents.
+// When fields are selected with WithFields, the fields needed by the query are requested as well.
+func (c *Client) RunQuery(ctx context.Context, q *Query, opts ...StatusOption) ([]QueryResult, int, error) {
+	if len(newStatusOptions(opts).fields) != 0 {
+		for _, key := range q.Sort {
+			opts = append(opts, WithFields(key.Field))
+		}
+	}
+
+	torrents, err := c.FindTorrents(ctx, q.Filter, opts...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	results := make([]QueryResult, 0, len(torrents))
+	for id, ts := range torrents {
+		results = append(results, QueryResult{ID: id, Status: ts})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return q.less(results[i], results[j])
+	})
+
+	total := len(results)
+	if q.Offset >= total {
+		return nil, total, nil
+	}
+	results = results[q.Offset:]
+	if q.Limit > 0 && q.Limit < len(results) {
+		results = results[:q.Limit]
+	}
+
+	return results, total, nil
+}
+
+// Search parses and runs a query, see ParseQuery and RunQuery.
+func (c *Client) Search(ctx context.Context, query string, opts ...StatusOption) ([]QueryResult, int, error) {
+	q, err := ParseQuery(query)
+	if err != nil {
+		return nil, 0, err
+	}
+	return c.RunQuery(ctx, q, opts...)
+}
@@ -0,0 +1,96 @@
+package deluge
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gdm85/go-rencode"
+)
+
+func TestParseQueryErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		query string
+		err   string
+	}{
+		{`sed_time>14d`, `did you mean "seed_time"?`},
+		{`ratio>abc`, `invalid value "abc"`},
+		{`name~"(`, `unterminated quoted value`},
+		{`name~"("`, `invalid regular expression`},
+		{`label:tv label:movies`, `label only supports a single ':' term`},
+		{`ratio~2`, `operator "~" is not supported by ratio`},
+		{`limit:-1`, `limit must be a positive integer`},
+		{`sort:label`, `cannot sort by label`},
+	}
+	for _, test := range tests {
+		_, err := ParseQuery(test.query)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: expected error containing %q, got %v", test.query, test.err, err)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	t.Parallel()
+
+	torrent := func(name string, ratio float32, seedingTime, size int64) rencode.Dictionary {
+		var d rencode.Dictionary
+		d.Add("name", name)
+		d.Add("ratio", ratio)
+		d.Add("seeding_time", seedingTime)
+		d.Add("total_size", size)
+		return d
+	}
+
+	c := newFakeClientV2(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		vals := normalizeRencode(args).([]interface{})
+		expectedFilter := map[string]interface{}{"state": "Seeding", "label": "tv", "tracker_host": "example.org"}
+		if !reflect.DeepEqual(vals[0], expectedFilter) {
+			t.Errorf("unexpected daemon filter %v", vals[0])
+		}
+
+		var d rencode.Dictionary
+		d.Add("a", torrent("Show.S01E01", 2.5, 86400*15, 1<<30))
+		d.Add("b", torrent("Show.S01E02", 4, 86400*20, 2<<30))
+		d.Add("c", torrent("Show.S01E03", 3, 86400*20, 3<<30))
+		d.Add("d", torrent("Show.S02E01", 5, 86400*20, 1<<30))
+		d.Add("e", torrent("Show.S01E04", 1, 86400*20, 1<<30))
+		d.Add("f", torrent("Show.S01E05", 3, 86400, 1<<30))
+		return d, nil
+	})
+
+	results, total, err := c.Search(context.Background(),
+		`state:seeding label:TV ratio>2 seed_time>14d tracker:example.org name~"S01" size<=3G sort:-ratio,name limit:2 offset:1`,
+		WithFields(FieldState))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 3 {
+		t.Errorf("unexpected total %d", total)
+	}
+	var ids []string
+	for _, r := range results {
+		ids = append(ids, r.ID)
+	}
+	if !reflect.DeepEqual(ids, []string{"c", "a"}) {
+		t.Errorf("unexpected results %v", ids)
+	}
+}
+
+func TestParseQueryBareWord(t *testing.T) {
+	t.Parallel()
+
+	q, err := ParseQuery(`ubuntu "server iso"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Filter.Match(&TorrentStatus{Name: "Ubuntu-22.04-Server-ISO"}) {
+		t.Error("unexpected match")
+	}
+	if !q.Filter.Match(&TorrentStatus{Name: "Ubuntu Server ISO"}) {
+		t.Error("expected a match")
+	}
+}
@@ -0,0 +1,158 @@
+// go-libdeluge v0.5.6 - a native deluge RPC client library
+// Copyright (C) 2015~2023 gdm85 - https://github.com/gdm85/go-libdeluge/
+// This program is free software; you can redistribute it and/or
+// modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; either version 2
+// of the License, or (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// You should have received a copy of the GNU General Public License
+// along with this program; if not, write to the Free Software
+// Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+
+package deluge
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/gdm85/go-rencode"
+)
+
+// QueueTop moves the torrents with the given IDs to the top of the queue.
+func (c *Client) QueueTop(ctx context.Context, ids ...string) error {
+	return c.queueMove(ctx, "core.queue_top", ids)
+}
+
+// QueueUp moves the torrents with the given IDs one position up in the queue.
+func (c *Client) QueueUp(ctx context.Context, ids ...string) error {
+	return c.queueMove(ctx, "core.queue_up", ids)
+}
+
+// QueueDown moves the torrents with the given IDs one position down in the queue.
+func (c *Client) QueueDown(ctx context.Context, ids ...string) error {
+	return c.queueMove(ctx, "core.queue_down", ids)
+}
+
+// QueueBottom moves the torrents with the given IDs to the bottom of the queue.
+func (c *Client) QueueBottom(ctx context.Context, ids ...string) error {
+	return c.queueMove(ctx, "core.queue_bottom", ids)
+}
+
+func (c *Client) queueMove(ctx context.Context, method string, ids []string) error {
+	var args rencode.List
+	args.Add(sliceToRencodeList(ids))
+
+	return c.rpcWithNoResult(ctx, method, args, rencode.Dictionary{})
+}
+
+// QueuePositions returns the queue position of every torrent in the session.
+// Torrents which are not queued (e.g. finished ones) have a position of -1.
+func (c *Client) QueuePositions(ctx context.Context) (map[string]int64, error) {
+	d, err := c.torrentsStatusKeys(ctx, rencode.Dictionary{}, "queue")
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]int64, len(d))
+	for id, v := range d {
+		var s struct {
+			Queue int64
+		}
+		err = v.ToStruct(&s, "")
+		if err != nil {
+			return nil, err
+		}
+		result[id] = s.Queue
+	}
+
+	return result, nil
+}
+
+// Reorder moves the torrents with the given IDs to the top of the queue, so that
+// the first ID ends up at position 0, the second at position 1 and so on.
+// Only queue_top is used: the shortest prefix of orderedIDs which is not already
+// followed by the rest of them in order is moved to the top again, one torrent at a time.
+// An error is returned if any of the torrents is unknown or not queued.
+func (c *Client) Reorder(ctx context.Context, orderedIDs []string) error {
+	positions, err := c.QueuePositions(ctx)
+	if err != nil {
+		return err
+	}
+
+	moves, err := reorderMoves(positions, orderedIDs)
+	if err != nil {
+		return err
+	}
+
+	// each move puts the torrent at the very top, so apply them bottom-up
+	for i := len(moves) - 1; i >= 0; i-- {
+		err = c.QueueTop(ctx, moves[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reorderMoves returns the shortest prefix of orderedIDs which must be moved to the
+// top of the queue so that the remaining IDs already follow it in the right order.
+func reorderMoves(positions map[string]int64, orderedIDs []string) ([]string, error) {
+	seen := make(map[string]bool, len(orderedIDs))
+	for _, id := range orderedIDs {
+		pos, ok := positions[id]
+		if !ok {
+			return nil, fmt.Errorf("torrent %q not found", id)
+		}
+		if pos < 0 {
+			return nil, fmt.Errorf("torrent %q is not queued", id)
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("torrent %q specified more than once", id)
+		}
+		seen[id] = true
+	}
+
+	var queue []string
+	for id, pos := range positions {
+		if pos >= 0 {
+			queue = append(queue, id)
+		}
+	}
+	sort.Slice(queue, func(i, j int) bool {
+		return positions[queue[i]] < positions[queue[j]]
+	})
+
+	for m := 0; m < len(orderedIDs); m++ {
+		moved := make(map[string]bool, m)
+		for _, id := range orderedIDs[:m] {
+			moved[id] = true
+		}
+
+		// the torrents not moved keep their relative order after the moved ones
+		inPlace := true
+		i := 0
+		for _, id := range queue {
+			if i == len(orderedIDs)-m {
+				break
+			}
+			if moved[id] {
+				continue
+			}
+			if id != orderedIDs[m+i] {
+				inPlace = false
+				break
+			}
+			i++
+		}
+		if inPlace {
+			return orderedIDs[:m], nil
+		}
+	}
+
+	return orderedIDs, nil
+}
@@ -0,0 +1,89 @@
+package deluge
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/gdm85/go-rencode"
+)
+
+func TestReorderMoves(t *testing.T) {
+	t.Parallel()
+
+	positions := map[string]int64{
+		"a": 0,
+		"b": 1,
+		"c": 2,
+		"d": 3,
+		"e": -1,
+	}
+
+	tests := []struct {
+		ordered []string
+		moves   []string
+	}{
+		{[]string{"a", "b"}, nil},
+		{[]string{"c"}, []string{"c"}},
+		{[]string{"d", "a", "b"}, []string{"d"}},
+		{[]string{"b", "a"}, []string{"b"}},
+		{[]string{"c", "d"}, []string{"c", "d"}},
+	}
+	for _, tt := range tests {
+		moves, err := reorderMoves(positions, tt.ordered)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(moves) != len(tt.moves) || (len(moves) != 0 && !reflect.DeepEqual(moves, tt.moves)) {
+			t.Errorf("%v: expected moves %v, got %v", tt.ordered, tt.moves, moves)
+		}
+	}
+
+	_, err := reorderMoves(positions, []string{"e"})
+	if err == nil {
+		t.Error("expected error for a torrent which is not queued")
+	}
+	_, err = reorderMoves(positions, []string{"x"})
+	if err == nil {
+		t.Error("expected error for an unknown torrent")
+	}
+}
+
+func TestReorder(t *testing.T) {
+	t.Parallel()
+
+	var moved []string
+	c := newFakeClientV2(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		switch method {
+		case "core.get_torrents_status":
+			var d rencode.Dictionary
+			for i, id := range []string{"a", "b", "c"} {
+				var st rencode.Dictionary
+				st.Add("queue", i)
+				d.Add(id, st)
+			}
+			return d, nil
+		case "core.queue_top":
+			var ids rencode.List
+			err := args.Scan(&ids)
+			if err != nil {
+				return nil, err
+			}
+			for _, id := range ids.Values() {
+				moved = append(moved, string(id.([]byte)))
+			}
+			return nil, nil
+		}
+		return nil, RPCError{ExceptionType: "AttributeError", ExceptionMessage: method}
+	})
+
+	err := c.Reorder(context.Background(), []string{"c", "b", "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"b", "c"}
+	if !reflect.DeepEqual(moved, expected) {
+		t.Errorf("expected moves %v, got %v", expected, moved)
+	}
+}
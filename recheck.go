@@ -0,0 +1,151 @@
+// go-libdeluge v0.5.6 - a native deluge RPC client library
+// Copyright (C) 2015~2023 gdm85 - https://github.com/gdm85/go-libdeluge/
+// This program is free software; you can redistribute it and/or
+// modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; either version 2
+// of the License, or (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// You should have received a copy of the GNU General Public License
+// along with this program; if not, write to the Free Software
+// Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+
+package deluge
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gdm85/go-rencode"
+)
+
+// DefaultPollInterval is the interval used by helpers polling the daemon when none is specified.
+const DefaultPollInterval = time.Second * 2
+
+// RecheckProgressFunc is called with the check progress (max is 100) of a torrent
+// which is still being checked.
+type RecheckProgressFunc func(id string, progress float32)
+
+// ForceRecheck forces a data recheck of the torrents with the given IDs.
+func (c *Client) ForceRecheck(ctx context.Context, ids ...string) error {
+	var args rencode.List
+	args.Add(sliceToRencodeList(ids))
+
+	return c.rpcWithNoResult(ctx, "core.force_recheck", args, rencode.Dictionary{})
+}
+
+// ForceRecheckAndWait forces a data recheck of the torrents with the given IDs and
+// then waits for the check to finish, see WaitRecheck.
+func (c *Client) ForceRecheckAndWait(ctx context.Context, ids []string, interval time.Duration, progress RecheckProgressFunc) ([]string, error) {
+	err := c.ForceRecheck(ctx, ids...)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.WaitRecheck(ctx, ids, interval, progress)
+}
+
+// recheckPendingPolls is the number of polls after which a torrent never seen checking,
+// whose state did not change either, is considered checked; a small torrent can be checked
+// between two polls.
+const recheckPendingPolls = 3
+
+// WaitRecheck polls the torrents with the given IDs every interval until all of them
+// have been checked; progress is optional and is called on every poll for each torrent
+// still being checked.
+// As the daemon updates the state asynchronously, a torrent is considered checked once it
+// was seen in the Checking state, or its state changed, and it is no longer Checking.
+// A Queued torrent is waiting for its check until it was seen Checking, afterwards it is
+// queued for downloading or seeding; use ctx to bound the wait for a torrent which was
+// Queued before the check and never seen Checking.
+// The IDs of the torrents which ended in the Error state are returned.
+func (c *Client) WaitRecheck(ctx context.Context, ids []string, interval time.Duration, progress RecheckProgressFunc) ([]string, error) {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+
+	var filterDict rencode.Dictionary
+	filterDict.Add("id", sliceToRencodeList(ids))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	firstStates := make(map[string]TorrentState, len(ids))
+	started := make(map[string]bool, len(ids))
+	checking := make(map[string]bool, len(ids))
+	done := make(map[string]bool, len(ids))
+	var failed []string
+	for polls := 1; ; polls++ {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+
+		d, err := c.torrentsStatusKeys(ctx, filterDict, "state", "progress")
+		if err != nil {
+			return nil, err
+		}
+
+		pending := false
+		for _, id := range ids {
+			if done[id] {
+				continue
+			}
+			v, ok := d[id]
+			if !ok {
+				return nil, fmt.Errorf("torrent %q not found", id)
+			}
+
+			var s struct {
+				State    string
+				Progress float32
+			}
+			err = v.ToStruct(&s, "")
+			if err != nil {
+				return nil, err
+			}
+
+			state := TorrentState(s.State)
+			if polls == 1 {
+				firstStates[id] = state
+			} else if state != firstStates[id] {
+				started[id] = true
+			}
+
+			switch {
+			case state == StateChecking:
+				started[id] = true
+				checking[id] = true
+				pending = true
+				if progress != nil {
+					progress(id, s.Progress)
+				}
+				continue
+			case state == StateQueued && !checking[id]:
+				// waiting for another torrent to be checked
+				pending = true
+				continue
+			}
+			if !started[id] && polls < recheckPendingPolls {
+				// the state might not have been updated yet
+				pending = true
+				continue
+			}
+
+			done[id] = true
+			if state == StateError {
+				failed = append(failed, id)
+			}
+		}
+
+		if !pending {
+			sort.Strings(failed)
+			return failed, nil
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package deluge
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gdm85/go-rencode"
+)
+
+func TestForceRecheckAndWait(t *testing.T) {
+	t.Parallel()
+
+	polls := 0
+	c := newFakeClientV2(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		switch method {
+		case "core.force_recheck":
+			return nil, nil
+		case "core.get_torrents_status":
+			polls++
+			states := map[string]string{"a": "Seeding", "b": "Error"}
+			switch polls {
+			case 1:
+				// the state is not updated yet right after force_recheck
+				states["a"], states["b"] = "Seeding", "Seeding"
+			case 2:
+				states["a"], states["b"] = "Checking", "Queued"
+			case 3:
+				states["b"] = "Checking"
+			}
+
+			var d rencode.Dictionary
+			for _, id := range []string{"a", "b"} {
+				var st rencode.Dictionary
+				st.Add("state", states[id])
+				st.Add("progress", float32(50))
+				d.Add(id, st)
+			}
+			return d, nil
+		}
+		return nil, RPCError{ExceptionType: "AttributeError", ExceptionMessage: method}
+	})
+
+	var reported []string
+	failed, err := c.ForceRecheckAndWait(context.Background(), []string{"a", "b"}, time.Millisecond, func(id string, progress float32) {
+		reported = append(reported, id)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(failed, []string{"b"}) {
+		t.Errorf("unexpected failed torrents %v", failed)
+	}
+	if !reflect.DeepEqual(reported, []string{"a", "b"}) {
+		t.Errorf("unexpected progress reports %v", reported)
+	}
+	if polls != 4 {
+		t.Errorf("expected 4 polls, got %d", polls)
+	}
+}
+
+func TestWaitRecheckUnchangedState(t *testing.T) {
+	t.Parallel()
+
+	polls := 0
+	c := newFakeClientV2(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		polls++
+		// checked between two polls
+		var st rencode.Dictionary
+		st.Add("state", "Seeding")
+		st.Add("progress", float32(100))
+		var d rencode.Dictionary
+		d.Add("a", st)
+		return d, nil
+	})
+
+	failed, err := c.WaitRecheck(context.Background(), []string{"a"}, time.Millisecond, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failed) != 0 {
+		t.Errorf("unexpected failed torrents %v", failed)
+	}
+	if polls != recheckPendingPolls {
+		t.Errorf("expected %d polls, got %d", recheckPendingPolls, polls)
+	}
+}
+
+func TestWaitRecheckQueuedAfterCheck(t *testing.T) {
+	t.Parallel()
+
+	polls := 0
+	c := newFakeClientV2(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		polls++
+		// queued for the check, then queued for download by the queue limits
+		states := []string{"Queued", "Checking", "Queued"}
+		state := states[len(states)-1]
+		if polls <= len(states) {
+			state = states[polls-1]
+		}
+		var st rencode.Dictionary
+		st.Add("state", state)
+		st.Add("progress", float32(100))
+		var d rencode.Dictionary
+		d.Add("a", st)
+		return d, nil
+	})
+
+	failed, err := c.WaitRecheck(context.Background(), []string{"a"}, time.Millisecond, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failed) != 0 {
+		t.Errorf("unexpected failed torrents %v", failed)
+	}
+	if polls != 3 {
+		t.Errorf("expected 3 polls, got %d", polls)
+	}
+}
@@ -0,0 +1,229 @@
+// go-libdeluge v0.5.6 - a native deluge RPC client library
+// Copyright (C) 2015~2023 gdm85 - https://github.com/gdm85/go-libdeluge/
+// This program is free software; you can redistribute it and/or
+// modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; either version 2
+// of the License, or (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// You should have received a copy of the GNU General Public License
+// along with this program; if not, write to the Free Software
+// Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+
+package deluge
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gdm85/go-rencode"
+)
+
+var (
+	// ErrPathNotFound is returned when a path does not exist on the daemon host.
+	ErrPathNotFound = errors.New("path not found")
+)
+
+// GetPathSize returns the size in bytes of a file or directory on the daemon host;
+// ErrPathNotFound is returned if it does not exist.
+func (c *Client) GetPathSize(ctx context.Context, path string) (int64, error) {
+	var args rencode.List
+	args.Add(path)
+
+	resp, err := c.rpc(ctx, "core.get_path_size", args, rencode.Dictionary{})
+	if err != nil {
+		return 0, err
+	}
+	if resp.IsError() {
+		return 0, resp.RPCError
+	}
+
+	var size int64
+	err = resp.returnValue.Scan(&size)
+	if err != nil {
+		return 0, err
+	}
+	if size < 0 {
+		return 0, ErrPathNotFound
+	}
+
+	return size, nil
+}
+
+// Glob returns the paths on the daemon host matching a shell pattern, as Python's glob.glob.
+func (c *Client) Glob(ctx context.Context, pattern string) ([]string, error) {
+	var args rencode.List
+	args.Add(pattern)
+
+	return c.rpcWithStringsListResult(ctx, "core.glob", args)
+}
+
+// GetCompletionPaths returns the directories on the daemon host completing the given
+// text, each with a trailing path separator.
+func (c *Client) GetCompletionPaths(ctx context.Context, text string, showHidden bool) ([]string, error) {
+	var dict rencode.Dictionary
+	dict.Add("completion_text", text)
+	dict.Add("show_hidden_files", showHidden)
+
+	var args rencode.List
+	args.Add(dict)
+
+	rd, err := c.rpcWithDictionaryResult(ctx, "core.get_completion_paths", args, rencode.Dictionary{})
+	if err != nil {
+		return nil, err
+	}
+
+	v, _ := rd.Get("paths")
+	var paths []string
+	err = assignRencode(v, reflect.ValueOf(&paths).Elem())
+	if err != nil {
+		return nil, err
+	}
+
+	return paths, nil
+}
+
+func (c *Client) rpcWithStringsListResult(ctx context.Context, method string, args rencode.List) ([]string, error) {
+	resp, err := c.rpc(ctx, method, args, rencode.Dictionary{})
+	if err != nil {
+		return nil, err
+	}
+	if resp.IsError() {
+		return nil, resp.RPCError
+	}
+
+	var list rencode.List
+	err = resp.returnValue.Scan(&list)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]string, list.Length())
+	for i, v := range list.Values() {
+		b, ok := v.([]byte)
+		if !ok {
+			return nil, ErrInvalidReturnValue
+		}
+		result[i] = string(b)
+	}
+
+	return result, nil
+}
+
+// RemoteFS browses the filesystem of the daemon host, e.g. to pick a download location.
+type RemoteFS struct {
+	c *Client
+}
+
+// RemoteFS returns a view of the filesystem of the daemon host.
+func (c *Client) RemoteFS() *RemoteFS {
+	return &RemoteFS{c: c}
+}
+
+// RemoteEntry is a file or directory on the daemon host.
+type RemoteEntry struct {
+	Name  string
+	Path  string
+	IsDir bool
+}
+
+// List returns the entries of a directory sorted by name, directories first;
+// hidden entries are skipped. Sizes are not included as computing them may be
+// slow, see Size.
+func (fs *RemoteFS) List(ctx context.Context, dir string) ([]RemoteEntry, error) {
+	sep := remotePathSeparator(dir)
+	if !strings.HasSuffix(dir, sep) {
+		dir += sep
+	}
+
+	paths, err := fs.c.Glob(ctx, globEscape(dir)+"*")
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		// tell an empty directory from a missing one
+		_, err = fs.c.GetPathSize(ctx, dir)
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	dirs, err := fs.c.GetCompletionPaths(ctx, dir, false)
+	if err != nil {
+		return nil, err
+	}
+	isDir := make(map[string]bool, len(dirs))
+	for _, d := range dirs {
+		isDir[strings.TrimSuffix(d, sep)] = true
+	}
+
+	entries := make([]RemoteEntry, len(paths))
+	for i, p := range paths {
+		entries[i] = RemoteEntry{
+			Name:  p[strings.LastIndex(p, sep)+1:],
+			Path:  p,
+			IsDir: isDir[p],
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].IsDir != entries[j].IsDir {
+			return entries[i].IsDir
+		}
+		return entries[i].Name < entries[j].Name
+	})
+
+	return entries, nil
+}
+
+// Size returns the size in bytes of a file or of the whole content of a directory.
+func (fs *RemoteFS) Size(ctx context.Context, path string) (int64, error) {
+	return fs.c.GetPathSize(ctx, path)
+}
+
+// Complete returns the directories completing a partially typed path.
+func (fs *RemoteFS) Complete(ctx context.Context, text string) ([]string, error) {
+	return fs.c.GetCompletionPaths(ctx, text, false)
+}
+
+// FreeSpace returns the free space in bytes of the filesystem of each path,
+// e.g. the download location and the move completed path.
+func (fs *RemoteFS) FreeSpace(ctx context.Context, paths ...string) (map[string]int64, error) {
+	result := make(map[string]int64, len(paths))
+	for _, p := range paths {
+		free, err := fs.c.GetFreeSpace(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		result[p] = free
+	}
+	return result, nil
+}
+
+// remotePathSeparator guesses the path separator of the daemon host from a path.
+func remotePathSeparator(path string) string {
+	if strings.Contains(path, `\`) && !strings.Contains(path, "/") {
+		return `\`
+	}
+	return "/"
+}
+
+// globEscape escapes the glob metacharacters of a literal path.
+func globEscape(path string) string {
+	var sb strings.Builder
+	for _, r := range path {
+		switch r {
+		case '*', '?', '[':
+			sb.WriteByte('[')
+			sb.WriteRune(r)
+			sb.WriteByte(']')
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
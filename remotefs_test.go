@@ -0,0 +1,59 @@
+package deluge
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/gdm85/go-rencode"
+)
+
+func TestRemoteFSList(t *testing.T) {
+	t.Parallel()
+
+	c := newFakeClientV2(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		vals := normalizeRencode(args).([]interface{})
+		switch method {
+		case "core.glob":
+			if vals[0] != "/data/[[]new]/*" {
+				t.Errorf("unexpected pattern %v", vals[0])
+			}
+			return rencode.NewList("/data/[new]/movie.mkv", "/data/[new]/tv", "/data/[new]/a.txt"), nil
+		case "core.get_completion_paths":
+			var d rencode.Dictionary
+			d.Add("completion_text", "/data/[new]/")
+			d.Add("show_hidden_files", false)
+			d.Add("paths", rencode.NewList("/data/[new]/tv/"))
+			return d, nil
+		}
+		return nil, RPCError{ExceptionType: "AttributeError", ExceptionMessage: method}
+	})
+
+	entries, err := c.RemoteFS().List(context.Background(), "/data/[new]")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []RemoteEntry{
+		{Name: "tv", Path: "/data/[new]/tv", IsDir: true},
+		{Name: "a.txt", Path: "/data/[new]/a.txt"},
+		{Name: "movie.mkv", Path: "/data/[new]/movie.mkv"},
+	}
+	if !reflect.DeepEqual(entries, expected) {
+		t.Errorf("unexpected entries %v", entries)
+	}
+}
+
+func TestGetPathSizeNotFound(t *testing.T) {
+	t.Parallel()
+
+	c := newFakeClient(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		return -1, nil
+	})
+
+	_, err := c.RemoteFS().Size(context.Background(), "/missing")
+	if !errors.Is(err, ErrPathNotFound) {
+		t.Errorf("expected ErrPathNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,225 @@
+// go-libdeluge v0.5.6 - a native deluge RPC client library
+// Copyright (C) 2015~2023 gdm85 - https://github.com/gdm85/go-libdeluge/
+// This program is free software; you can redistribute it and/or
+// modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; either version 2
+// of the License, or (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// You should have received a copy of the GNU General Public License
+// along with this program; if not, write to the Free Software
+// Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+
+package deluge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/gdm85/go-rencode"
+)
+
+var (
+	// ErrInvalidPath is returned when a path inside a torrent is empty, absolute or escapes the torrent.
+	ErrInvalidPath = errors.New("invalid path")
+)
+
+// RenameFiles renames files of the torrent with the given hash; renames maps each
+// file index to its new path, relative to the torrent root.
+// The renames are validated against the current list of files before being sent.
+func (c *Client) RenameFiles(ctx context.Context, id string, renames map[int]string) error {
+	files, err := c.TorrentFiles(ctx, id)
+	if err != nil {
+		return err
+	}
+	_, err = PreviewRenameFiles(files, renames)
+	if err != nil {
+		return err
+	}
+
+	indexes := make([]int, 0, len(renames))
+	for i := range renames {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	var filenames rencode.List
+	for _, i := range indexes {
+		p, _ := normalizeTorrentPath(renames[i])
+		filenames.Add(rencode.NewList(i, p))
+	}
+
+	var args rencode.List
+	args.Add(id, filenames)
+
+	return c.rpcWithNoResult(ctx, "core.rename_files", args, rencode.Dictionary{})
+}
+
+// RenameFolder renames a folder of the torrent with the given hash, moving all
+// the files it contains.
+// The rename is validated against the current list of files before being sent.
+func (c *Client) RenameFolder(ctx context.Context, id, oldPath, newPath string) error {
+	files, err := c.TorrentFiles(ctx, id)
+	if err != nil {
+		return err
+	}
+	_, err = PreviewRenameFolder(files, oldPath, newPath)
+	if err != nil {
+		return err
+	}
+
+	// deluge matches folders by prefix, the trailing slash avoids renaming siblings
+	oldPath, _ = normalizeTorrentPath(oldPath)
+	newPath, _ = normalizeTorrentPath(newPath)
+
+	var args rencode.List
+	args.Add(id, oldPath+"/", newPath+"/")
+
+	return c.rpcWithNoResult(ctx, "core.rename_folder", args, rencode.Dictionary{})
+}
+
+// PreviewRenameFiles returns the files as they would be after RenameFiles, or an
+// error if any index is unknown, any path is invalid or two files would collide.
+func PreviewRenameFiles(files []File, renames map[int]string) ([]File, error) {
+	result := make([]File, len(files))
+	copy(result, files)
+
+	byIndex := make(map[int64]int, len(result))
+	for i, f := range result {
+		byIndex[f.Index] = i
+	}
+
+	for index, newPath := range renames {
+		i, ok := byIndex[int64(index)]
+		if !ok {
+			return nil, fmt.Errorf("file index %d not found", index)
+		}
+		p, err := normalizeTorrentPath(newPath)
+		if err != nil {
+			return nil, fmt.Errorf("file index %d: %w", index, err)
+		}
+		result[i].Path = p
+	}
+
+	return result, checkPathCollisions(result)
+}
+
+// PreviewRenameFolder returns the files as they would be after RenameFolder, or an
+// error if the folder does not exist, any path is invalid or two files would collide.
+func PreviewRenameFolder(files []File, oldPath, newPath string) ([]File, error) {
+	from, err := normalizeTorrentPath(oldPath)
+	if err != nil {
+		return nil, fmt.Errorf("folder %q: %w", oldPath, err)
+	}
+	to, err := normalizeTorrentPath(newPath)
+	if err != nil {
+		return nil, fmt.Errorf("folder %q: %w", newPath, err)
+	}
+
+	result := make([]File, len(files))
+	copy(result, files)
+
+	found := false
+	for i, f := range result {
+		if strings.HasPrefix(f.Path, from+"/") {
+			result[i].Path = to + strings.TrimPrefix(f.Path, from)
+			found = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("folder %q not found", from)
+	}
+
+	return result, checkPathCollisions(result)
+}
+
+// FileTree renders the paths of the files as an indented tree, one entry per line.
+func FileTree(files []File) string {
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.Path
+	}
+	sort.Strings(paths)
+
+	var sb strings.Builder
+	var prev []string
+	for _, p := range paths {
+		parts := strings.Split(p, "/")
+
+		// skip the folders already printed for the previous path
+		common := 0
+		for common < len(prev)-1 && common < len(parts)-1 && prev[common] == parts[common] {
+			common++
+		}
+		for depth := common; depth < len(parts); depth++ {
+			sb.WriteString(strings.Repeat("  ", depth))
+			sb.WriteString(parts[depth])
+			if depth < len(parts)-1 {
+				sb.WriteString("/")
+			}
+			sb.WriteString("\n")
+		}
+		prev = parts
+	}
+
+	return sb.String()
+}
+
+// normalizeTorrentPath converts separators to forward slashes and cleans the path,
+// rejecting empty and absolute paths or paths with parent references.
+func normalizeTorrentPath(p string) (string, error) {
+	p = strings.ReplaceAll(p, "\\", "/")
+	if strings.HasPrefix(p, "/") || hasDriveLetter(p) {
+		return "", ErrInvalidPath
+	}
+	for _, part := range strings.Split(p, "/") {
+		if part == ".." {
+			return "", ErrInvalidPath
+		}
+	}
+
+	p = path.Clean(p)
+	if p == "." {
+		return "", ErrInvalidPath
+	}
+
+	return p, nil
+}
+
+// hasDriveLetter reports whether a path with forward slashes starts with a
+// Windows drive like "C:" or "C:/"; "A: Story.mkv" is a valid name.
+func hasDriveLetter(p string) bool {
+	if len(p) < 2 || p[1] != ':' {
+		return false
+	}
+	letter := p[0] | 0x20
+	return letter >= 'a' && letter <= 'z' && (len(p) == 2 || p[2] == '/')
+}
+
+// checkPathCollisions returns an error if two files share a path or if a file
+// path is also used as a folder by another file.
+func checkPathCollisions(files []File) error {
+	seen := make(map[string]bool, len(files))
+	for _, f := range files {
+		if seen[f.Path] {
+			return fmt.Errorf("path %q used more than once", f.Path)
+		}
+		seen[f.Path] = true
+	}
+
+	for _, f := range files {
+		for dir := path.Dir(f.Path); dir != "."; dir = path.Dir(dir) {
+			if seen[dir] {
+				return fmt.Errorf("path %q is both a file and a folder", dir)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package deluge
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gdm85/go-rencode"
+)
+
+var testFiles = []File{
+	{Index: 0, Path: "Show.S01/Show.S01E01.mkv"},
+	{Index: 1, Path: "Show.S01/Show.S01E02.mkv"},
+	{Index: 2, Path: "Show.S01/Sample/sample.mkv"},
+}
+
+func TestPreviewRenameFiles(t *testing.T) {
+	t.Parallel()
+
+	files, err := PreviewRenameFiles(testFiles, map[int]string{0: `Show.S01\Episode 1.mkv`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files[0].Path != "Show.S01/Episode 1.mkv" {
+		t.Errorf("unexpected path %q", files[0].Path)
+	}
+	if testFiles[0].Path != "Show.S01/Show.S01E01.mkv" {
+		t.Error("original files were modified")
+	}
+
+	_, err = PreviewRenameFiles(testFiles, map[int]string{0: "Show.S01/Show.S01E02.mkv"})
+	if err == nil {
+		t.Error("expected collision error")
+	}
+
+	_, err = PreviewRenameFiles(testFiles, map[int]string{0: "Show.S01/Sample"})
+	if err == nil {
+		t.Error("expected file/folder collision error")
+	}
+
+	files, err = PreviewRenameFiles(testFiles, map[int]string{0: "A: Story.mkv"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files[0].Path != "A: Story.mkv" {
+		t.Errorf("unexpected path %q", files[0].Path)
+	}
+
+	for _, p := range []string{"../escape.mkv", "/etc/passwd", "a/../../b", "", "C:", `C:\Windows\x.mkv`, "d:/x.mkv"} {
+		_, err = PreviewRenameFiles(testFiles, map[int]string{0: p})
+		if !errors.Is(err, ErrInvalidPath) {
+			t.Errorf("%q: expected ErrInvalidPath, got %v", p, err)
+		}
+	}
+
+	_, err = PreviewRenameFiles(testFiles, map[int]string{5: "x"})
+	if err == nil {
+		t.Error("expected unknown index error")
+	}
+}
+
+func TestPreviewRenameFolder(t *testing.T) {
+	t.Parallel()
+
+	files, err := PreviewRenameFolder(testFiles, "Show.S01", "Show (2020)/Season 01")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const expected = `Show (2020)/
+  Season 01/
+    Sample/
+      sample.mkv
+    Show.S01E01.mkv
+    Show.S01E02.mkv
+`
+	if tree := FileTree(files); tree != expected {
+		t.Errorf("expected tree:\n%s\ngot:\n%s", expected, tree)
+	}
+
+	_, err = PreviewRenameFolder(testFiles, "Show.S0", "Other")
+	if err == nil {
+		t.Error("expected folder not found error")
+	}
+}
+
+func TestRenameFolder(t *testing.T) {
+	t.Parallel()
+
+	var renamed []string
+	c := newFakeClientV2(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		switch method {
+		case "core.get_torrent_status":
+			var files rencode.List
+			for _, f := range testFiles {
+				var d rencode.Dictionary
+				d.Add("index", f.Index)
+				d.Add("size", f.Size)
+				d.Add("offset", f.Offset)
+				d.Add("path", f.Path)
+				files.Add(d)
+			}
+			var st rencode.Dictionary
+			st.Add("files", files)
+			return st, nil
+		case "core.rename_folder":
+			var id, from, to string
+			err := args.Scan(&id, &from, &to)
+			if err != nil {
+				return nil, err
+			}
+			renamed = append(renamed, from, to)
+			return nil, nil
+		}
+		return nil, RPCError{ExceptionType: "AttributeError", ExceptionMessage: method}
+	})
+
+	err := c.RenameFolder(context.Background(), testMagnetHash, `Show.S01\`, "Show")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(renamed) != 2 || renamed[0] != "Show.S01/" || renamed[1] != "Show/" {
+		t.Errorf("unexpected rename_folder arguments %v", renamed)
+	}
+}
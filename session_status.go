@@ -52,3 +52,32 @@ func (c *Client) GetSessionStatus(ctx context.Context) (*SessionStatus, error) {
 
 	return &data, nil
 }
+
+// PauseSession pauses the whole session, i.e. all torrents.
+func (c *Client) PauseSession(ctx context.Context) error {
+	return c.rpcWithNoResult(ctx, "core.pause_session", rencode.List{}, rencode.Dictionary{})
+}
+
+// ResumeSession resumes the whole session after it was paused with PauseSession.
+func (c *Client) ResumeSession(ctx context.Context) error {
+	return c.rpcWithNoResult(ctx, "core.resume_session", rencode.List{}, rencode.Dictionary{})
+}
+
+// IsSessionPaused returns true when the whole session is paused.
+func (c *Client) IsSessionPaused(ctx context.Context) (bool, error) {
+	resp, err := c.rpc(ctx, "core.is_session_paused", rencode.List{}, rencode.Dictionary{})
+	if err != nil {
+		return false, err
+	}
+	if resp.IsError() {
+		return false, resp.RPCError
+	}
+
+	var paused bool
+	err = resp.returnValue.Scan(&paused)
+	if err != nil {
+		return false, err
+	}
+
+	return paused, nil
+}
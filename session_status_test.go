@@ -0,0 +1,53 @@
+package deluge
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gdm85/go-rencode"
+)
+
+func TestPauseSession(t *testing.T) {
+	t.Parallel()
+
+	paused := false
+	c := newFakeClientV2(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		switch method {
+		case "core.pause_session":
+			paused = true
+			return nil, nil
+		case "core.is_session_paused":
+			return paused, nil
+		}
+		return nil, RPCError{ExceptionType: "AttributeError", ExceptionMessage: method}
+	})
+
+	err := c.PauseSession(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok, err := c.IsSessionPaused(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Error("expected session to be paused")
+	}
+}
+
+func TestSessionState(t *testing.T) {
+	t.Parallel()
+
+	c := newFakeClient(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		return rencode.NewList(testMagnetHash), nil
+	})
+
+	ids, err := c.SessionState(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 1 || ids[0] != testMagnetHash {
+		t.Errorf("unexpected session state %v", ids)
+	}
+}
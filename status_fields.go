@@ -0,0 +1,155 @@
+// go-libdeluge v0.5.6 - a native deluge RPC client library
+// Copyright (C) 2015~2023 gdm85 - https://github.com/gdm85/go-libdeluge/
+// This program is free software; you can redistribute it and/or
+// modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; either version 2
+// of the License, or (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// You should have received a copy of the GNU General Public License
+// along with this program; if not, write to the Free Software
+// Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+
+package deluge
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/gdm85/go-rencode"
+)
+
+// StatusField is a torrent status key, matching a TorrentStatus field.
+type StatusField string
+
+const (
+	FieldActiveTime          StatusField = "active_time"
+	FieldCompletedTime       StatusField = "completed_time" // v2-only
+	FieldTimeAdded           StatusField = "time_added"
+	FieldLastSeenComplete    StatusField = "last_seen_complete" // v2-only
+	FieldDistributedCopies   StatusField = "distributed_copies"
+	FieldETA                 StatusField = "eta"
+	FieldProgress            StatusField = "progress"
+	FieldRatio               StatusField = "ratio"
+	FieldIsFinished          StatusField = "is_finished"
+	FieldIsSeed              StatusField = "is_seed"
+	FieldPrivate             StatusField = "private"
+	FieldSavePath            StatusField = "save_path"
+	FieldDownloadLocation    StatusField = "download_location" // read from save_path on v1
+	FieldDownloadPayloadRate StatusField = "download_payload_rate"
+	FieldName                StatusField = "name"
+	FieldHash                StatusField = "hash"
+	FieldNextAnnounce        StatusField = "next_announce"
+	FieldNumPeers            StatusField = "num_peers"
+	FieldNumPieces           StatusField = "num_pieces"
+	FieldNumSeeds            StatusField = "num_seeds"
+	FieldPieceLength         StatusField = "piece_length"
+	FieldSeedingTime         StatusField = "seeding_time"
+	FieldState               StatusField = "state"
+	FieldTotalDone           StatusField = "total_done"
+	FieldTotalPeers          StatusField = "total_peers"
+	FieldTotalSeeds          StatusField = "total_seeds"
+	FieldTotalSize           StatusField = "total_size"
+	FieldTrackerHost         StatusField = "tracker_host"
+	FieldTrackerStatus       StatusField = "tracker_status"
+	FieldUploadPayloadRate   StatusField = "upload_payload_rate"
+	FieldQueue               StatusField = "queue"
+	FieldFiles               StatusField = "files"
+	FieldPeers               StatusField = "peers"
+	FieldFilePriorities      StatusField = "file_priorities"
+	FieldFileProgress        StatusField = "file_progress"
+)
+
+// StatusOption customizes the status requested by TorrentStatus and TorrentsStatus.
+type StatusOption func(*statusOptions)
+
+type statusOptions struct {
+	fields []StatusField
+}
+
+// WithFields requests only the given fields; the other TorrentStatus fields are left empty.
+// By default all the fields are requested, including heavy ones like Files and Peers.
+func WithFields(fields ...StatusField) StatusOption {
+	return func(o *statusOptions) {
+		o.fields = append(o.fields, fields...)
+	}
+}
+
+func newStatusOptions(opts []StatusOption) statusOptions {
+	var o statusOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// keys returns the status keys to request.
+func (o statusOptions) keys(v2daemon bool) rencode.List {
+	if len(o.fields) == 0 {
+		if !v2daemon {
+			return statusKeysV1
+		}
+		return statusKeysV2
+	}
+
+	var keys rencode.List
+	seen := map[StatusField]bool{}
+	for _, f := range o.fields {
+		if !v2daemon && f == FieldDownloadLocation {
+			f = FieldSavePath
+		}
+		if seen[f] {
+			continue
+		}
+		seen[f] = true
+		keys.Add(string(f))
+	}
+	return keys
+}
+
+// decode converts a status dictionary; when fields are selected the missing keys
+// are left empty instead of causing an error.
+func (o statusOptions) decode(rd rencode.Dictionary, excludeTag string, v2daemon bool) (*TorrentStatus, error) {
+	var ts TorrentStatus
+	var err error
+	if len(o.fields) == 0 {
+		err = rd.ToStruct(&ts, excludeTag)
+	} else {
+		err = assignRencode(rd, reflect.ValueOf(&ts).Elem())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// on v2 both fields SavePath and DownloadLocation are already set to the correct values
+	if !v2daemon {
+		// on v1 be forward-compatible with v2
+		ts.DownloadLocation = ts.SavePath
+	}
+
+	return &ts, nil
+}
+
+// TorrentsStatusMap returns the raw status of the torrents matching the filter,
+// limited to the given keys which can also be provided by plugins (e.g. "label").
+// Values are converted as by GetConfigValue.
+func (c *Client) TorrentsStatusMap(ctx context.Context, filter TorrentFilter, keys ...string) (map[string]map[string]interface{}, error) {
+	list := make([]interface{}, len(keys))
+	for i, k := range keys {
+		list[i] = k
+	}
+
+	d, err := c.torrentsStatusKeys(ctx, filter.toDictionary(), list...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]map[string]interface{}, len(d))
+	for id, rd := range d {
+		result[id] = normalizeRencode(rd).(map[string]interface{})
+	}
+
+	return result, nil
+}
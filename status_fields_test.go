@@ -0,0 +1,64 @@
+package deluge
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/gdm85/go-rencode"
+)
+
+func TestTorrentsStatusWithFields(t *testing.T) {
+	t.Parallel()
+
+	c := newFakeClient(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		vals := normalizeRencode(args).([]interface{})
+		expected := []interface{}{"state", "progress", "save_path"}
+		if !reflect.DeepEqual(vals[1], expected) {
+			t.Errorf("unexpected keys %v", vals[1])
+		}
+
+		var status rencode.Dictionary
+		status.Add("state", "Seeding")
+		status.Add("progress", float32(100))
+		status.Add("save_path", "/data")
+		var d rencode.Dictionary
+		d.Add(testMagnetHash, status)
+		return d, nil
+	})
+
+	torrents, err := c.TorrentsStatus(context.Background(), StateUnspecified, nil, WithFields(FieldState, FieldProgress, FieldDownloadLocation, FieldState))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &TorrentStatus{State: "Seeding", Progress: 100, SavePath: "/data", DownloadLocation: "/data"}
+	if !reflect.DeepEqual(torrents[testMagnetHash], expected) {
+		t.Errorf("unexpected status %+v", torrents[testMagnetHash])
+	}
+}
+
+func TestTorrentsStatusMap(t *testing.T) {
+	t.Parallel()
+
+	c := newFakeClientV2(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		var status rencode.Dictionary
+		status.Add("label", "movies")
+		status.Add("num_seeds", 3)
+		var d rencode.Dictionary
+		d.Add(testMagnetHash, status)
+		return d, nil
+	})
+
+	torrents, err := c.TorrentsStatusMap(context.Background(), TorrentFilter{Label: "movies"}, "label", "num_seeds")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]map[string]interface{}{
+		testMagnetHash: {"label": "movies", "num_seeds": int64(3)},
+	}
+	if !reflect.DeepEqual(torrents, expected) {
+		t.Errorf("unexpected status %v", torrents)
+	}
+}
@@ -0,0 +1,226 @@
+// go-libdeluge v0.5.6 - a native deluge RPC client library
+// Copyright (C) 2015~2023 gdm85 - https://github.com/gdm85/go-libdeluge/
+// This program is free software; you can redistribute it and/or
+// modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; either version 2
+// of the License, or (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// You should have received a copy of the GNU General Public License
+// along with this program; if not, write to the Free Software
+// Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+
+package deluge
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/gdm85/go-rencode"
+)
+
+// StatusChange describes how the status of a torrent changed between two polls.
+type StatusChange struct {
+	ID string
+	// Fields are the changed fields, sorted; all the fields for added torrents.
+	Fields  []StatusField
+	Added   bool
+	Removed bool
+	// Status is the current status, nil for removed torrents.
+	Status *TorrentStatus
+}
+
+// StatusWatcher keeps an up to date copy of the status of the torrents matching a
+// filter, using the diff mode of the daemon so that only changed fields are transferred.
+// The daemon keeps the last status sent per connection, therefore a client should not
+// be used by more than one watcher nor for other diff mode calls.
+// A StatusWatcher is not safe for concurrent use.
+type StatusWatcher struct {
+	c      *Client
+	filter TorrentFilter
+	o      statusOptions
+
+	raw      map[string]map[string]interface{}
+	torrents map[string]*TorrentStatus
+	// synced is false until a full snapshot has been received, and after errors
+	synced bool
+}
+
+// NewStatusWatcher returns a watcher for the torrents matching the filter; see WithFields
+// to limit the watched fields.
+func (c *Client) NewStatusWatcher(filter TorrentFilter, opts ...StatusOption) *StatusWatcher {
+	return &StatusWatcher{
+		c:        c,
+		filter:   filter,
+		o:        newStatusOptions(opts),
+		raw:      map[string]map[string]interface{}{},
+		torrents: map[string]*TorrentStatus{},
+	}
+}
+
+// Torrents returns the status of the watched torrents as of the last poll;
+// the map must not be modified and is updated by the following polls.
+func (w *StatusWatcher) Torrents() map[string]*TorrentStatus {
+	return w.torrents
+}
+
+// Poll retrieves the changes since the previous poll, sorted by torrent ID.
+// The first poll, and the first one after an error, retrieves a full snapshot.
+func (w *StatusWatcher) Poll(ctx context.Context) ([]StatusChange, error) {
+	result, err := w.fetch(ctx, w.filter, w.synced)
+	if err != nil {
+		w.synced = false
+		return nil, err
+	}
+
+	if w.synced {
+		// the daemon keeps the status last sent for torrents which stopped matching the
+		// filter, so that a torrent matching it again is only returned as a partial diff
+		var unknown []string
+		for id := range result {
+			if _, ok := w.raw[id]; !ok {
+				unknown = append(unknown, id)
+			}
+		}
+		if len(unknown) != 0 {
+			full, err := w.fetch(ctx, TorrentFilter{IDs: unknown}, false)
+			if err != nil {
+				w.synced = false
+				return nil, err
+			}
+			for _, id := range unknown {
+				if status, ok := full[id]; ok {
+					result[id] = status
+				} else {
+					delete(result, id)
+				}
+			}
+		}
+	}
+
+	var changes []StatusChange
+	for id, v := range result {
+		delta, ok := v.(map[string]interface{})
+		if !ok {
+			w.synced = false
+			return nil, ErrInvalidDictionaryResponse
+		}
+		change, err := w.merge(id, delta)
+		if err != nil {
+			w.synced = false
+			return nil, err
+		}
+		if change != nil {
+			changes = append(changes, *change)
+		}
+	}
+
+	// torrents removed from the session, or not matching the filter anymore, are not returned
+	for id := range w.raw {
+		if _, ok := result[id]; !ok {
+			delete(w.raw, id)
+			delete(w.torrents, id)
+			changes = append(changes, StatusChange{ID: id, Removed: true})
+		}
+	}
+
+	w.synced = true
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].ID < changes[j].ID
+	})
+
+	return changes, nil
+}
+
+// fetch returns the normalized status of the torrents matching the filter.
+func (w *StatusWatcher) fetch(ctx context.Context, filter TorrentFilter, diff bool) (map[string]interface{}, error) {
+	var args rencode.List
+	args.Add(filter.toDictionary())
+	args.Add(w.o.keys(w.c.v2daemon))
+	var kwargs rencode.Dictionary
+	if diff {
+		kwargs.Add("diff", true)
+	}
+
+	rd, err := w.c.rpcWithDictionaryResult(ctx, "core.get_torrents_status", args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+	result, ok := normalizeRencode(rd).(map[string]interface{})
+	if !ok {
+		return nil, ErrInvalidDictionaryResponse
+	}
+
+	return result, nil
+}
+
+// merge applies the changed values of a torrent and returns nil when nothing changed;
+// the values of a full snapshot are compared to detect the actual changes.
+func (w *StatusWatcher) merge(id string, delta map[string]interface{}) (*StatusChange, error) {
+	raw, known := w.raw[id]
+	if !known {
+		raw = map[string]interface{}{}
+	}
+
+	var fields []StatusField
+	for k, v := range delta {
+		if known {
+			if old, ok := raw[k]; ok && reflect.DeepEqual(old, v) {
+				continue
+			}
+		}
+		raw[k] = v
+		fields = append(fields, StatusField(k))
+	}
+	if known && len(fields) == 0 {
+		return nil, nil
+	}
+
+	var ts TorrentStatus
+	err := assignRencode(raw, reflect.ValueOf(&ts).Elem())
+	if err != nil {
+		return nil, err
+	}
+	if !w.c.v2daemon {
+		// on v1 be forward-compatible with v2
+		ts.DownloadLocation = ts.SavePath
+	}
+
+	w.raw[id] = raw
+	w.torrents[id] = &ts
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i] < fields[j]
+	})
+
+	return &StatusChange{ID: id, Fields: fields, Added: !known, Status: &ts}, nil
+}
+
+// Watch polls at the given interval (DefaultPollInterval when zero) and calls fn with
+// the changes, when any, until the context is done or a poll fails.
+func (w *StatusWatcher) Watch(ctx context.Context, interval time.Duration, fn func([]StatusChange)) error {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		changes, err := w.Poll(ctx)
+		if err != nil {
+			return err
+		}
+		if len(changes) != 0 {
+			fn(changes)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
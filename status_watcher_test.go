@@ -0,0 +1,145 @@
+package deluge
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/gdm85/go-rencode"
+)
+
+func TestStatusWatcher(t *testing.T) {
+	t.Parallel()
+
+	status := func(state string, progress float32) rencode.Dictionary {
+		var d rencode.Dictionary
+		d.Add("state", state)
+		d.Add("progress", progress)
+		return d
+	}
+
+	var diffs []bool
+	responses := []func() rencode.Dictionary{
+		func() rencode.Dictionary {
+			var d rencode.Dictionary
+			d.Add("a", status("Downloading", 10))
+			d.Add("b", status("Seeding", 100))
+			return d
+		},
+		func() rencode.Dictionary {
+			// the first diff mode call returns everything
+			var d rencode.Dictionary
+			var delta rencode.Dictionary
+			delta.Add("progress", float32(20))
+			d.Add("a", delta)
+			d.Add("b", status("Seeding", 100))
+			return d
+		},
+		func() rencode.Dictionary {
+			var d rencode.Dictionary
+			d.Add("b", rencode.Dictionary{})
+			return d
+		},
+	}
+	c := newFakeClientV2(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		_, diff := kwargs.Get("diff")
+		diffs = append(diffs, diff)
+		r := responses[0]
+		responses = responses[1:]
+		return r(), nil
+	})
+
+	w := c.NewStatusWatcher(TorrentFilter{}, WithFields(FieldState, FieldProgress))
+
+	changes, err := w.Poll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 || !changes[0].Added || changes[0].ID != "a" || changes[0].Status.Progress != 10 {
+		t.Errorf("unexpected first changes %+v", changes)
+	}
+
+	changes, err = w.Poll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].ID != "a" || changes[0].Added || !reflect.DeepEqual(changes[0].Fields, []StatusField{FieldProgress}) {
+		t.Errorf("unexpected second changes %+v", changes)
+	}
+	if w.Torrents()["a"].Progress != 20 || w.Torrents()["a"].State != "Downloading" {
+		t.Errorf("unexpected merged status %+v", w.Torrents()["a"])
+	}
+
+	changes, err = w.Poll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].ID != "a" || !changes[0].Removed {
+		t.Errorf("unexpected third changes %+v", changes)
+	}
+	if len(w.Torrents()) != 1 {
+		t.Errorf("unexpected torrents %v", w.Torrents())
+	}
+
+	if !reflect.DeepEqual(diffs, []bool{false, true, true}) {
+		t.Errorf("unexpected diff mode calls %v", diffs)
+	}
+}
+
+func TestStatusWatcherReturningTorrent(t *testing.T) {
+	t.Parallel()
+
+	status := func(state string, progress float32) rencode.Dictionary {
+		var d rencode.Dictionary
+		d.Add("state", state)
+		d.Add("progress", progress)
+		return d
+	}
+
+	var calls []string
+	c := newFakeClientV2(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		_, diff := kwargs.Get("diff")
+		filter := normalizeRencode(args).([]interface{})[0].(map[string]interface{})
+		var d rencode.Dictionary
+		switch {
+		case !diff && len(filter) == 0:
+			calls = append(calls, "full")
+			d.Add("a", status("Downloading", 10))
+		case diff:
+			calls = append(calls, "diff")
+			// b matched the filter before the watcher was created, only its changes are sent
+			var delta rencode.Dictionary
+			delta.Add("progress", float32(50))
+			d.Add("a", rencode.Dictionary{})
+			d.Add("b", delta)
+		default:
+			calls = append(calls, "refetch")
+			if !reflect.DeepEqual(filter, map[string]interface{}{"id": []interface{}{"b"}}) {
+				t.Errorf("unexpected refetch filter %v", filter)
+			}
+			d.Add("b", status("Downloading", 50))
+		}
+		return d, nil
+	})
+
+	w := c.NewStatusWatcher(TorrentFilter{}, WithFields(FieldState, FieldProgress))
+
+	_, err := w.Poll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes, err := w.Poll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(changes) != 1 || changes[0].ID != "b" || !changes[0].Added {
+		t.Fatalf("unexpected changes %+v", changes)
+	}
+	if changes[0].Status.State != "Downloading" || changes[0].Status.Progress != 50 {
+		t.Errorf("unexpected status %+v", changes[0].Status)
+	}
+	if !reflect.DeepEqual(calls, []string{"full", "diff", "refetch"}) {
+		t.Errorf("unexpected calls %v", calls)
+	}
+}
@@ -0,0 +1,172 @@
+// go-libdeluge v0.5.6 - a native deluge RPC client library
+// Copyright (C) 2015~2023 gdm85 - https://github.com/gdm85/go-libdeluge/
+// This program is free software; you can redistribute it and/or
+// modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; either version 2
+// of the License, or (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// You should have received a copy of the GNU General Public License
+// along with this program; if not, write to the Free Software
+// Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+
+package deluge
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// optionStatusKeysV1 are the status keys of the options which are named differently on v1 daemons.
+var optionStatusKeysV1 = map[string]string{
+	"auto_managed":                 "is_auto_managed",
+	"move_completed":               "move_on_completed",
+	"move_completed_path":          "move_on_completed_path",
+	"prioritize_first_last_pieces": "prioritize_first_last",
+	"download_location":            "save_path",
+	"compact_allocation":           "compact",
+}
+
+// optionStatusKeysV2 are the status keys of the options which are named differently on v2 daemons.
+var optionStatusKeysV2 = map[string]string{
+	// "sparse" or "allocate", see storageModeAllocate
+	"pre_allocate_storage": "storage_mode",
+}
+
+// storageModeAllocate is the storage_mode status of the torrents with preallocated storage.
+const storageModeAllocate = "allocate"
+
+// addOnlyOptions are only applied when adding a torrent and cannot be read back.
+var addOnlyOptions = map[string]bool{
+	"add_paused":   true,
+	"mapped_files": true,
+	"seed_mode":    true,
+}
+
+// TorrentOptions returns the current options of the torrents with the given IDs, or of all
+// the torrents when none is specified. AddPaused, MappedFiles and SeedMode are only
+// meaningful when adding a torrent and are left nil; PreAllocateStorage is derived from
+// the storage mode of the torrent; the v2-only options are only read from
+// v2 daemons and CompactAllocation only from v1 daemons.
+func (c *Client) TorrentOptions(ctx context.Context, ids ...string) (map[string]*Options, error) {
+	var keys []interface{}
+	for _, f := range optionFields(&Options{}) {
+		if addOnlyOptions[f.key] || !f.supported(c.v2daemon) {
+			continue
+		}
+		keys = append(keys, c.optionStatusKey(f.key))
+	}
+
+	d, err := c.torrentsStatusKeys(ctx, TorrentFilter{IDs: ids}.toDictionary(), keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]*Options, len(d))
+	for id, rd := range d {
+		raw, ok := normalizeRencode(rd).(map[string]interface{})
+		if !ok {
+			return nil, ErrInvalidDictionaryResponse
+		}
+
+		var o Options
+		for _, f := range optionFields(&o) {
+			v, ok := raw[c.optionStatusKey(f.key)]
+			if !ok || addOnlyOptions[f.key] || !f.supported(c.v2daemon) {
+				continue
+			}
+			switch f.key {
+			case "file_priorities":
+				err = o.setFilePriorities(v, c.v2daemon)
+			case "pre_allocate_storage":
+				err = o.setPreAllocateStorage(v)
+			default:
+				err = assignRencode(v, f.value)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("torrent %s: option %s: %w", id, f.key, err)
+			}
+		}
+		result[id] = &o
+	}
+
+	return result, nil
+}
+
+func (c *Client) optionStatusKey(key string) string {
+	keys := optionStatusKeysV1
+	if c.v2daemon {
+		keys = optionStatusKeysV2
+	}
+	if k, ok := keys[key]; ok {
+		return k
+	}
+	return key
+}
+
+func (o *Options) setPreAllocateStorage(v interface{}) error {
+	var mode string
+	err := assignRencode(v, reflect.ValueOf(&mode).Elem())
+	if err != nil {
+		return err
+	}
+	allocate := mode == storageModeAllocate
+	o.PreAllocateStorage = &allocate
+	return nil
+}
+
+func (o *Options) setFilePriorities(v interface{}, v2daemon bool) error {
+	var wire []int64
+	err := assignRencode(v, reflect.ValueOf(&wire).Elem())
+	if err != nil {
+		return err
+	}
+	o.FilePriorities = make([]FilePriority, len(wire))
+	for i, p := range wire {
+		o.FilePriorities[i] = filePriorityFromWire(p, v2daemon)
+	}
+	return nil
+}
+
+// OptionDiff is an option with a different value in two Options.
+type OptionDiff struct {
+	// Key is the option key, e.g. "max_connections".
+	Key string
+	// Current and Desired are the dereferenced values, nil when not set.
+	Current interface{}
+	Desired interface{}
+}
+
+// DiffOptions returns the options set in desired whose value is different in current,
+// in the order of the Options fields; options not set in desired are ignored.
+// Applying the desired options only for the returned keys makes current match desired.
+func DiffOptions(current, desired *Options) []OptionDiff {
+	if desired == nil {
+		return nil
+	}
+	if current == nil {
+		current = &Options{}
+	}
+
+	currentFields := optionFields(current)
+	var diffs []OptionDiff
+	for i, f := range optionFields(desired) {
+		if f.value.IsNil() {
+			continue
+		}
+		d := OptionDiff{Key: f.key, Desired: reflect.Indirect(f.value).Interface()}
+		cf := currentFields[i].value
+		if !cf.IsNil() {
+			d.Current = reflect.Indirect(cf).Interface()
+			if valuesEqual(normalizeRencode(d.Current), normalizeRencode(d.Desired)) {
+				continue
+			}
+		}
+		diffs = append(diffs, d)
+	}
+
+	return diffs
+}
@@ -0,0 +1,149 @@
+package deluge
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/gdm85/go-rencode"
+)
+
+func TestTorrentOptions(t *testing.T) {
+	t.Parallel()
+
+	c := newFakeClientV2(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		vals := normalizeRencode(args).([]interface{})
+		if !reflect.DeepEqual(vals[0], map[string]interface{}{"id": []interface{}{testMagnetHash}}) {
+			t.Errorf("got filter %v", vals[0])
+		}
+		keys := vals[1].([]interface{})
+		for _, k := range keys {
+			if k == "add_paused" || k == "compact_allocation" || k == "pre_allocate_storage" {
+				t.Errorf("%s requested", k)
+			}
+		}
+
+		var status rencode.Dictionary
+		status.Add("max_connections", int64(120))
+		status.Add("stop_ratio", float32(1.5))
+		status.Add("auto_managed", true)
+		status.Add("download_location", "/downloads")
+		status.Add("file_priorities", rencode.NewList(int64(4), int64(0)))
+		status.Add("sequential_download", true)
+		status.Add("storage_mode", "allocate")
+		var d rencode.Dictionary
+		d.Add(testMagnetHash, status)
+		return d, nil
+	})
+
+	options, err := c.TorrentOptions(context.Background(), testMagnetHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := options[testMagnetHash]
+	if o == nil {
+		t.Fatal("torrent missing")
+	}
+	if o.MaxConnections == nil || *o.MaxConnections != 120 {
+		t.Errorf("got max connections %v", o.MaxConnections)
+	}
+	if o.StopRatio == nil || *o.StopRatio != 1.5 {
+		t.Errorf("got stop ratio %v", o.StopRatio)
+	}
+	if o.DownloadLocation == nil || *o.DownloadLocation != "/downloads" {
+		t.Errorf("got download location %v", o.DownloadLocation)
+	}
+	if !reflect.DeepEqual(o.FilePriorities, []FilePriority{FilePriorityNormal, FilePrioritySkip}) {
+		t.Errorf("got file priorities %v", o.FilePriorities)
+	}
+	if o.PreAllocateStorage == nil || !*o.PreAllocateStorage {
+		t.Errorf("got pre-allocate storage %v", o.PreAllocateStorage)
+	}
+	if o.V2.SequentialDownload == nil || !*o.V2.SequentialDownload {
+		t.Errorf("got sequential download %v", o.V2.SequentialDownload)
+	}
+	if o.MaxUploadSlots != nil {
+		t.Errorf("missing option set to %v", *o.MaxUploadSlots)
+	}
+}
+
+func TestTorrentOptionsV1(t *testing.T) {
+	t.Parallel()
+
+	c := newFakeClient(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		keys := normalizeRencode(args).([]interface{})[1].([]interface{})
+		for _, k := range keys {
+			if k == "download_location" || k == "sequential_download" {
+				t.Errorf("v2 key %q requested", k)
+			}
+		}
+
+		var status rencode.Dictionary
+		status.Add("is_auto_managed", true)
+		status.Add("move_on_completed_path", "/done")
+		status.Add("save_path", "/downloads")
+		status.Add("compact", true)
+		status.Add("file_priorities", rencode.NewList(int64(1), int64(2)))
+		var d rencode.Dictionary
+		d.Add(testMagnetHash, status)
+		return d, nil
+	})
+
+	options, err := c.TorrentOptions(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := options[testMagnetHash]
+	if o.AutoManaged == nil || !*o.AutoManaged {
+		t.Errorf("got auto managed %v", o.AutoManaged)
+	}
+	if o.MoveCompletedPath == nil || *o.MoveCompletedPath != "/done" {
+		t.Errorf("got move completed path %v", o.MoveCompletedPath)
+	}
+	if o.DownloadLocation == nil || *o.DownloadLocation != "/downloads" {
+		t.Errorf("got download location %v", o.DownloadLocation)
+	}
+	if o.CompactAllocation == nil || !*o.CompactAllocation {
+		t.Errorf("got compact allocation %v", o.CompactAllocation)
+	}
+	if !reflect.DeepEqual(o.FilePriorities, []FilePriority{FilePriorityNormal, FilePriorityHigh}) {
+		t.Errorf("got file priorities %v", o.FilePriorities)
+	}
+}
+
+func TestDiffOptions(t *testing.T) {
+	t.Parallel()
+
+	maxConns, otherConns := 100, 50
+	ratio, sameRatio := float32(2.1), float32(2.1)
+	tVal, fVal := true, false
+	path := "/done"
+
+	current := &Options{
+		MaxConnections:    &maxConns,
+		StopRatio:         &ratio,
+		AutoManaged:       &tVal,
+		MoveCompletedPath: &path,
+		V2:                V2Options{Shared: &fVal},
+	}
+	desired := &Options{
+		MaxConnections: &otherConns,
+		StopRatio:      &sameRatio,
+		StopAtRatio:    &tVal,
+		V2:             V2Options{Shared: &tVal},
+	}
+
+	diffs := DiffOptions(current, desired)
+	expected := []OptionDiff{
+		{Key: "max_connections", Current: 100, Desired: 50},
+		{Key: "stop_at_ratio", Desired: true},
+		{Key: "shared", Current: false, Desired: true},
+	}
+	if !reflect.DeepEqual(diffs, expected) {
+		t.Errorf("got diffs %v", diffs)
+	}
+
+	if diffs := DiffOptions(current, current); len(diffs) != 0 {
+		t.Errorf("got diffs %v for identical options", diffs)
+	}
+}
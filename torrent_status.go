@@ -24,7 +24,8 @@ import (
 // by the deluge server.
 // The full list of potentially available attributes can be found here:
 // https://github.com/deluge-torrent/deluge/blob/deluge-2.0.3/deluge/core/torrent.py#L1033-L1143
-// If a new field is added to this struct it should also be added to the statusKeys map.
+// If a new field is added to this struct it should also be added to the statusKeys map
+// and to the StatusField constants.
 type TorrentStatus struct {
 	ActiveTime          int64
 	CompletedTime       int64   `rencode:"v2only"`
@@ -56,10 +57,11 @@ type TorrentStatus struct {
 	TrackerHost         string
 	TrackerStatus       string
 	UploadPayloadRate   int64
+	Queue               int64 // -1 when the torrent is not queued, e.g. when finished
 
 	Files          []File
 	Peers          []Peer
-	FilePriorities []int64
+	FilePriorities []int64 // raw daemon values, see Client.FilePriorities
 	FileProgress   []float32
 }
 
@@ -115,6 +117,7 @@ var (
 		"seeding_time",
 		"time_added",
 		"private",
+		"queue",
 		"save_path", // supported by both v1 and v2
 	}
 	statusKeysV1 = rencode.NewList(commonStatusKeys...)
@@ -126,52 +129,35 @@ var (
 )
 
 // TorrentStatus returns the status of the torrent with specified hash.
-func (c *Client) TorrentStatus(ctx context.Context, hash string) (*TorrentStatus, error) {
+func (c *Client) TorrentStatus(ctx context.Context, hash string, opts ...StatusOption) (*TorrentStatus, error) {
+	o := newStatusOptions(opts)
+
 	var args rencode.List
 	args.Add(hash)
-	if !c.v2daemon {
-		args.Add(statusKeysV1)
-	} else {
-		args.Add(statusKeysV2)
-	}
+	args.Add(o.keys(c.v2daemon))
 
 	rd, err := c.rpcWithDictionaryResult(ctx, "core.get_torrent_status", args, rencode.Dictionary{})
 	if err != nil {
 		return nil, err
 	}
 
-	var ts TorrentStatus
-	err = rd.ToStruct(&ts, c.excludeTag)
-	if err != nil {
-		return nil, err
-	}
-
-	// on v2 both fields SavePath and DownloadLocation are already set to the correct values
-	if !c.v2daemon {
-		// on v1 be forward-compatible with v2
-		ts.DownloadLocation = ts.SavePath
-	}
-
-	return &ts, nil
+	return o.decode(rd, c.excludeTag, c.v2daemon)
 }
 
 // TorrentsStatus returns the status of torrents matching the specified state and list of hashes.
 // Both state and list of hashes are optional.
-func (c *Client) TorrentsStatus(ctx context.Context, state TorrentState, hashes []string) (map[string]*TorrentStatus, error) {
+func (c *Client) TorrentsStatus(ctx context.Context, state TorrentState, hashes []string, opts ...StatusOption) (map[string]*TorrentStatus, error) {
+	return c.TorrentsStatusFiltered(ctx, TorrentFilter{State: state, IDs: hashes}, opts...)
+}
+
+// TorrentsStatusFiltered returns the status of the torrents matching a daemon-side filter,
+// e.g. one built from a FilterTree node.
+func (c *Client) TorrentsStatusFiltered(ctx context.Context, filter TorrentFilter, opts ...StatusOption) (map[string]*TorrentStatus, error) {
+	o := newStatusOptions(opts)
+
 	var args rencode.List
-	var filterDict rencode.Dictionary
-	if len(hashes) != 0 {
-		filterDict.Add("id", sliceToRencodeList(hashes))
-	}
-	if state != StateUnspecified {
-		filterDict.Add("state", string(state))
-	}
-	args.Add(filterDict)
-	if !c.v2daemon {
-		args.Add(statusKeysV1)
-	} else {
-		args.Add(statusKeysV2)
-	}
+	args.Add(filter.toDictionary())
+	args.Add(o.keys(c.v2daemon))
 
 	rd, err := c.rpcWithDictionaryResult(ctx, "core.get_torrents_status", args, rencode.Dictionary{})
 	if err != nil {
@@ -190,19 +176,62 @@ func (c *Client) TorrentsStatus(ctx context.Context, state TorrentState, hashes
 			return nil, ErrInvalidDictionaryResponse
 		}
 
-		var ts TorrentStatus
-		err = v.ToStruct(&ts, c.excludeTag)
+		ts, err := o.decode(v, c.excludeTag, c.v2daemon)
 		if err != nil {
 			return nil, err
 		}
+		result[k] = ts
+	}
 
-		// on v2 both fields SavePath and DownloadLocation are already set to the correct values
-		if !c.v2daemon {
-			// on v1 be forward-compatible with v2
-			ts.DownloadLocation = ts.SavePath
-		}
+	return result, nil
+}
+
+// TorrentFiles returns the list of files of the torrent with specified hash.
+func (c *Client) TorrentFiles(ctx context.Context, hash string) ([]File, error) {
+	var args rencode.List
+	args.Add(hash)
+	args.Add(rencode.NewList("files"))
+
+	rd, err := c.rpcWithDictionaryResult(ctx, "core.get_torrent_status", args, rencode.Dictionary{})
+	if err != nil {
+		return nil, err
+	}
+
+	var s struct {
+		Files []File
+	}
+	err = rd.ToStruct(&s, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Files, nil
+}
+
+// torrentsStatusKeys returns the raw status dictionaries of the torrents matching
+// the filter, limited to the specified keys.
+func (c *Client) torrentsStatusKeys(ctx context.Context, filterDict rencode.Dictionary, keys ...interface{}) (map[string]rencode.Dictionary, error) {
+	var args rencode.List
+	args.Add(filterDict)
+	args.Add(rencode.NewList(keys...))
+
+	rd, err := c.rpcWithDictionaryResult(ctx, "core.get_torrents_status", args, rencode.Dictionary{})
+	if err != nil {
+		return nil, err
+	}
 
-		result[k] = &ts
+	d, err := rd.Zip()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]rencode.Dictionary, len(d))
+	for k, rv := range d {
+		v, ok := rv.(rencode.Dictionary)
+		if !ok {
+			return nil, ErrInvalidDictionaryResponse
+		}
+		result[k] = v
 	}
 
 	return result, nil
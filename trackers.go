@@ -0,0 +1,181 @@
+// go-libdeluge v0.5.6 - a native deluge RPC client library
+// Copyright (C) 2015~2023 gdm85 - https://github.com/gdm85/go-libdeluge/
+// This program is free software; you can redistribute it and/or
+// modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; either version 2
+// of the License, or (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// You should have received a copy of the GNU General Public License
+// along with this program; if not, write to the Free Software
+// Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+
+package deluge
+
+import (
+	"context"
+
+	"github.com/gdm85/go-rencode"
+)
+
+// Tracker is a tracker of a torrent; trackers with a lower tier are tried first.
+type Tracker struct {
+	URL  string
+	Tier int64
+	// Message is the last message received from the tracker, only
+	// available when reported by the daemon's libtorrent version.
+	// TorrentStatus.TrackerStatus holds the last message of the current tracker.
+	Message string
+}
+
+func (t *Tracker) fromDictionary(dict rencode.Dictionary) error {
+	ok, err := scanDictionaryValue(dict, "url", &t.URL)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidReturnValue
+	}
+	_, err = scanDictionaryValue(dict, "tier", &t.Tier)
+	if err != nil {
+		return err
+	}
+
+	// libtorrent 1.2+ reports the message for each of the endpoints
+	_, err = scanDictionaryValue(dict, "message", &t.Message)
+	if err != nil || t.Message != "" {
+		return err
+	}
+	var endpoints rencode.List
+	ok, err = scanDictionaryValue(dict, "endpoints", &endpoints)
+	if err != nil || !ok {
+		return err
+	}
+	for _, e := range endpoints.Values() {
+		endpoint, isDict := e.(rencode.Dictionary)
+		if !isDict {
+			continue
+		}
+		_, err = scanDictionaryValue(endpoint, "message", &t.Message)
+		if err != nil || t.Message != "" {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t Tracker) toDictionary() rencode.Dictionary {
+	var dict rencode.Dictionary
+	dict.Add("url", t.URL)
+	dict.Add("tier", t.Tier)
+	return dict
+}
+
+// GetTrackers returns the trackers of the torrent with the given hash.
+func (c *Client) GetTrackers(ctx context.Context, id string) ([]Tracker, error) {
+	var args rencode.List
+	args.Add(id)
+	args.Add(rencode.NewList("trackers"))
+
+	rd, err := c.rpcWithDictionaryResult(ctx, "core.get_torrent_status", args, rencode.Dictionary{})
+	if err != nil {
+		return nil, err
+	}
+
+	var list rencode.List
+	ok, err := scanDictionaryValue(rd, "trackers", &list)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidReturnValue
+	}
+
+	trackers := make([]Tracker, 0, list.Length())
+	for _, v := range list.Values() {
+		dict, ok := v.(rencode.Dictionary)
+		if !ok {
+			return nil, ErrInvalidDictionaryResponse
+		}
+
+		var t Tracker
+		err = t.fromDictionary(dict)
+		if err != nil {
+			return nil, err
+		}
+		trackers = append(trackers, t)
+	}
+
+	return trackers, nil
+}
+
+// SetTrackers replaces all the trackers of the torrent with the given hash.
+func (c *Client) SetTrackers(ctx context.Context, id string, trackers []Tracker) error {
+	var list rencode.List
+	for _, t := range trackers {
+		list.Add(t.toDictionary())
+	}
+
+	var args rencode.List
+	args.Add(id, list)
+
+	return c.rpcWithNoResult(ctx, "core.set_torrent_trackers", args, rencode.Dictionary{})
+}
+
+// AddTrackers adds trackers to the torrent with the given hash, keeping the
+// existing ones with their tiers; trackers with an already known URL are ignored.
+func (c *Client) AddTrackers(ctx context.Context, id string, trackers ...Tracker) error {
+	current, err := c.GetTrackers(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	known := make(map[string]bool, len(current))
+	for _, t := range current {
+		known[t.URL] = true
+	}
+
+	changed := false
+	for _, t := range trackers {
+		if known[t.URL] {
+			continue
+		}
+		known[t.URL] = true
+		current = append(current, t)
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+
+	return c.SetTrackers(ctx, id, current)
+}
+
+// RemoveTrackers removes the trackers with the given URLs from the torrent with the
+// given hash, keeping the other ones with their tiers.
+func (c *Client) RemoveTrackers(ctx context.Context, id string, urls ...string) error {
+	current, err := c.GetTrackers(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	remove := make(map[string]bool, len(urls))
+	for _, u := range urls {
+		remove[u] = true
+	}
+
+	kept := current[:0]
+	for _, t := range current {
+		if !remove[t.URL] {
+			kept = append(kept, t)
+		}
+	}
+	if len(kept) == len(current) {
+		return nil
+	}
+
+	return c.SetTrackers(ctx, id, kept)
+}
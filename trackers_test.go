@@ -0,0 +1,80 @@
+package deluge
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/gdm85/go-rencode"
+)
+
+func TestAddTrackers(t *testing.T) {
+	t.Parallel()
+
+	var set []Tracker
+	c := newFakeClientV2(func(method string, args rencode.List, kwargs rencode.Dictionary) (interface{}, error) {
+		switch method {
+		case "core.get_torrent_status":
+			var endpoint rencode.Dictionary
+			endpoint.Add("message", "ok")
+			var primary rencode.Dictionary
+			primary.Add("url", "http://primary/announce")
+			primary.Add("tier", 0)
+			// libtorrent 1.2+ sends an empty message next to the endpoints
+			primary.Add("message", "")
+			primary.Add("endpoints", rencode.NewList(endpoint))
+			var backup rencode.Dictionary
+			backup.Add("url", "http://backup/announce")
+			backup.Add("tier", 3)
+			backup.Add("message", "")
+
+			var st rencode.Dictionary
+			st.Add("trackers", rencode.NewList(primary, backup))
+			return st, nil
+		case "core.set_torrent_trackers":
+			var (
+				id   string
+				list rencode.List
+			)
+			err := args.Scan(&id, &list)
+			if err != nil {
+				return nil, err
+			}
+			for _, v := range list.Values() {
+				var tr Tracker
+				err = tr.fromDictionary(v.(rencode.Dictionary))
+				if err != nil {
+					return nil, err
+				}
+				set = append(set, tr)
+			}
+			return nil, nil
+		}
+		return nil, RPCError{ExceptionType: "AttributeError", ExceptionMessage: method}
+	})
+
+	trackers, err := c.GetTrackers(context.Background(), testMagnetHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trackers) != 2 || trackers[0].Message != "ok" || trackers[1].Tier != 3 {
+		t.Errorf("unexpected trackers %+v", trackers)
+	}
+
+	err = c.AddTrackers(context.Background(), testMagnetHash,
+		Tracker{URL: "http://backup/announce", Tier: 1},
+		Tracker{URL: "http://new/announce", Tier: 1},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Tracker{
+		{URL: "http://primary/announce", Tier: 0},
+		{URL: "http://backup/announce", Tier: 3},
+		{URL: "http://new/announce", Tier: 1},
+	}
+	if !reflect.DeepEqual(set, expected) {
+		t.Errorf("expected trackers %+v, got %+v", expected, set)
+	}
+}